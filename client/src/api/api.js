@@ -164,16 +164,13 @@ export async function deleteInvestorAPI(id) {
 function normalizePayout(p) {
   if (!p) return null;
 
-  const rawDate = p.period_date || p.period_month || null;
-
-  const periodDate = rawDate ? String(rawDate).slice(0, 10) : null;
-  const periodMonth = rawDate ? String(rawDate).slice(0, 7) : null;
+  // period_month больше не используется — только period_date
+  const periodDate = p.period_date ? String(p.period_date).slice(0, 10) : null;
 
   return {
     id: p.id,
     investorId: p.investor_id,
     periodDate,
-    periodMonth,
 
     payoutAmount: Number(p.payout_amount),
 
@@ -12,9 +12,7 @@ export default function ExcelExporter({
   // РЕАЛЬНАЯ ДАТА ОПЕРАЦИИ
   // ================================
   function getRealDate(p) {
-    if (p.periodDate) return p.periodDate;            // YYYY-MM-DD
-    if (p.periodMonth) return p.periodMonth + "-01";  // старый формат
-    return null;
+    return p.periodDate || null; // YYYY-MM-DD
   }
 
   // =========================================
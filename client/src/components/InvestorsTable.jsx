@@ -73,13 +73,15 @@ export default function InvestorsTable({
 
     payouts.forEach((p) => {
       if (p.isTopup) return;
-      if (!p.periodMonth) return;
+      if (!p.periodDate) return;
 
-      if (!byMonthInv.has(p.periodMonth)) {
-        byMonthInv.set(p.periodMonth, new Map());
+      const month = p.periodDate.slice(0, 7); // YYYY-MM
+
+      if (!byMonthInv.has(month)) {
+        byMonthInv.set(month, new Map());
       }
 
-      const invMap = byMonthInv.get(p.periodMonth);
+      const invMap = byMonthInv.get(month);
       const list = invMap.get(p.investorId) || [];
       list.push(p);
       invMap.set(p.investorId, list);
@@ -29,7 +29,7 @@ export default function TopupHistoryModal({
   // фильтруем только пополнения
   const topups = payouts
     .filter((p) => p.investorId === investor.id && p.isTopup)
-    .sort((a, b) => (a.periodMonth || "").localeCompare(b.periodMonth || ""));
+    .sort((a, b) => (a.periodDate || "").localeCompare(b.periodDate || ""));
 
   return (
     <div className="fixed inset-0 bg-black/60 backdrop-blur-sm flex items-center justify-center z-50">
@@ -41,7 +41,7 @@ export default function TopupHistoryModal({
         </h2>
 
 {topups.map((t) => {
-  const rawDate = t.periodDate || t.periodMonth; // ← используем настоящую дату!
+  const rawDate = t.periodDate; // ← используем настоящую дату!
   const date = parseAnyDate(rawDate);
 
   const label = date
@@ -98,7 +98,7 @@ export async function generateInvestorPdfBlob({
   //
   const rows = payouts
     .filter((p) => p.investorId === investor.id)
-    .sort((a, b) => (a.periodMonth < b.periodMonth ? -1 : 1))
+    .sort((a, b) => (a.periodDate < b.periodDate ? -1 : 1))
     .map((p) => {
       let type = "";
       if (p.isTopup) type = "Пополнение капитала";
@@ -108,8 +108,8 @@ export async function generateInvestorPdfBlob({
       else type = "Операция";
 
       // форматируем дату
-      const formattedMonth = p.periodMonth
-        ? new Date(p.periodMonth + "-01").toLocaleDateString("ru-RU", {
+      const formattedMonth = p.periodDate
+        ? new Date(p.periodDate).toLocaleDateString("ru-RU", {
             month: "short",
             year: "2-digit",
           })
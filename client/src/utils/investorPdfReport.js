@@ -22,7 +22,6 @@ function parseAnyDate(p) {
 
   if (typeof p === "string" && p.length === 10) return new Date(p);
   if (p.periodDate) return new Date(p.periodDate);
-  if (p.periodMonth) return new Date(p.periodMonth + "-01");
 
   return null;
 }
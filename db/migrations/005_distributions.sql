@@ -0,0 +1,21 @@
+-- 005_distributions.sql
+-- Распределение прибыли за месяц одним запуском (POST /api/distributions)
+
+CREATE TABLE IF NOT EXISTS distributions (
+    id SERIAL PRIMARY KEY,
+    period_date DATE NOT NULL,
+    gross_percent NUMERIC(7,2) NOT NULL,
+    total_amount NUMERIC(18,2) NOT NULL DEFAULT 0,
+
+    created_by INT REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    -- откат всего запуска целиком
+    rolled_back_at TIMESTAMPTZ,
+    rolled_back_by INT REFERENCES users(id)
+);
+
+ALTER TABLE payouts
+ADD COLUMN IF NOT EXISTS distribution_id INT REFERENCES distributions(id);
+
+CREATE INDEX IF NOT EXISTS idx_payouts_distribution ON payouts(distribution_id);
@@ -0,0 +1,11 @@
+-- 006_payout_reversals.sql
+-- Сторно: отменяющая запись ссылается на исходную, исходная остаётся в истории
+
+ALTER TABLE payouts
+ADD COLUMN IF NOT EXISTS reversal_of INT UNIQUE REFERENCES payouts(id) ON DELETE CASCADE;
+
+ALTER TABLE payouts
+ADD COLUMN IF NOT EXISTS reversal_reason TEXT;
+
+ALTER TABLE payouts
+ADD COLUMN IF NOT EXISTS created_by INT REFERENCES users(id);
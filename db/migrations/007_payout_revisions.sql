@@ -0,0 +1,18 @@
+-- 007_payout_revisions.sql
+-- История правок и удалений выплат (PUT / DELETE /api/payouts/{id})
+
+CREATE TABLE IF NOT EXISTS payout_revisions (
+    id SERIAL PRIMARY KEY,
+
+    -- без внешнего ключа: история остаётся и после удаления выплаты
+    payout_id INT NOT NULL,
+
+    action TEXT NOT NULL CHECK (action IN ('update', 'delete')),
+    old_data JSONB NOT NULL,
+    new_data JSONB,
+
+    changed_by INT REFERENCES users(id),
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_payout_revisions_payout ON payout_revisions(payout_id);
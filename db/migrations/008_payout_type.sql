@@ -0,0 +1,50 @@
+-- 008_payout_type.sql
+-- Явный тип операции вместо четырёх флагов.
+-- Флаги остаются на переходный период и обязаны совпадать с type.
+
+ALTER TABLE payouts
+ADD COLUMN IF NOT EXISTS type TEXT;
+
+-- Перенос старых строк: при противоречивых флагах приоритет
+-- topup > capital_withdrawal > profit_withdrawal > reinvest.
+-- Строки без флагов считаются корректировками.
+UPDATE payouts
+SET type = CASE
+    WHEN is_topup THEN 'topup'
+    WHEN is_withdrawal_capital THEN 'capital_withdrawal'
+    WHEN is_withdrawal_profit THEN 'profit_withdrawal'
+    WHEN reinvest THEN 'reinvest'
+    ELSE 'adjustment'
+END
+WHERE type IS NULL;
+
+-- флаги приводим к выбранному типу
+UPDATE payouts
+SET reinvest = (type = 'reinvest'),
+    is_withdrawal_profit = (type = 'profit_withdrawal'),
+    is_withdrawal_capital = (type = 'capital_withdrawal'),
+    is_topup = (type = 'topup');
+
+ALTER TABLE payouts
+ALTER COLUMN type SET NOT NULL;
+
+ALTER TABLE payouts
+ADD CONSTRAINT payouts_type_check CHECK (type IN (
+    'reinvest',
+    'profit_withdrawal',
+    'capital_withdrawal',
+    'topup',
+    'fee',
+    'adjustment',
+    'tax'
+));
+
+ALTER TABLE payouts
+ADD CONSTRAINT payouts_flags_match_type CHECK (
+    reinvest = (type = 'reinvest')
+    AND is_withdrawal_profit = (type = 'profit_withdrawal')
+    AND is_withdrawal_capital = (type = 'capital_withdrawal')
+    AND is_topup = (type = 'topup')
+);
+
+CREATE INDEX IF NOT EXISTS idx_payouts_investor_type ON payouts(investor_id, type);
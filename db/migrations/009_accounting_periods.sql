@@ -0,0 +1,22 @@
+-- 009_accounting_periods.sql
+-- Закрытие месяцев: в закрытом периоде нельзя добавлять и менять операции
+
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS is_admin BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- первый зарегистрированный пользователь — администратор
+UPDATE users SET is_admin = TRUE
+WHERE id = (SELECT MIN(id) FROM users);
+
+CREATE TABLE IF NOT EXISTS accounting_periods (
+    -- первое число месяца
+    period_month DATE PRIMARY KEY
+        CHECK (period_month = date_trunc('month', period_month)::date),
+
+    is_closed BOOLEAN NOT NULL DEFAULT TRUE,
+
+    closed_at TIMESTAMPTZ,
+    closed_by INT REFERENCES users(id),
+    reopened_at TIMESTAMPTZ,
+    reopened_by INT REFERENCES users(id)
+);
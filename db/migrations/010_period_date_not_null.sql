@@ -0,0 +1,11 @@
+-- 010_period_date_not_null.sql
+-- Единое поле даты: period_month переносится в period_date.
+-- Для существующих баз сначала запустите cmd/migrate-period-date —
+-- он покажет строки, которые нельзя перенести автоматически.
+
+UPDATE payouts
+SET period_date = period_month
+WHERE period_date IS NULL AND period_month IS NOT NULL;
+
+ALTER TABLE payouts
+ALTER COLUMN period_date SET NOT NULL;
@@ -0,0 +1,36 @@
+-- 011_fund_units.sql
+-- Паевой учёт (FUND_MODE=units): пополнения покупают паи по текущей
+-- стоимости пая, снятия капитала погашают паи, месячный результат
+-- двигает стоимость пая.
+
+CREATE TABLE IF NOT EXISTS fund_nav (
+    id SERIAL PRIMARY KEY,
+    nav_date DATE NOT NULL UNIQUE,
+
+    nav_per_unit NUMERIC(20,6) NOT NULL CHECK (nav_per_unit > 0),
+    total_units NUMERIC(24,6) NOT NULL,
+    total_assets NUMERIC(18,2) NOT NULL,
+
+    -- результат месяца, если NAV задан процентом
+    result_percent NUMERIC(7,2),
+
+    created_by INT REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS investor_units (
+    id SERIAL PRIMARY KEY,
+    investor_id INT NOT NULL REFERENCES investors(id) ON DELETE CASCADE,
+
+    -- операция, породившая сделку; NULL — стартовая выдача паёв
+    payout_id INT UNIQUE REFERENCES payouts(id) ON DELETE CASCADE,
+
+    trade_date DATE NOT NULL,
+    units NUMERIC(24,6) NOT NULL, -- + покупка, − погашение
+    nav_per_unit NUMERIC(20,6) NOT NULL,
+    amount NUMERIC(18,2) NOT NULL,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_investor_units_investor ON investor_units(investor_id, trade_date);
@@ -0,0 +1,33 @@
+-- 012_fees.sql
+-- Комиссии управляющего: management (годовой % от капитала, списывается
+-- помесячно) и performance (% от прибыли выше high-water mark).
+-- Комиссии — отдельные строки payouts с type='fee' и fee_kind.
+
+ALTER TABLE investors
+ADD COLUMN IF NOT EXISTS management_fee_percent NUMERIC(5,2) NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS performance_fee_percent NUMERIC(5,2) NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS high_water_mark NUMERIC(18,2) NOT NULL DEFAULT 0;
+
+ALTER TABLE investors
+ADD CONSTRAINT investors_fee_percent_check CHECK (
+    management_fee_percent BETWEEN 0 AND 100
+    AND performance_fee_percent BETWEEN 0 AND 100
+);
+
+ALTER TABLE payouts
+ADD COLUMN IF NOT EXISTS fee_kind TEXT;
+
+-- старые строки с type='fee' (ручной ввод) считаем management
+UPDATE payouts
+SET fee_kind = 'management'
+WHERE type = 'fee' AND fee_kind IS NULL;
+
+ALTER TABLE payouts
+ADD CONSTRAINT payouts_fee_kind_check CHECK (
+    fee_kind IN ('management', 'performance')
+    AND type = 'fee'
+    OR fee_kind IS NULL AND type <> 'fee'
+);
+
+CREATE INDEX IF NOT EXISTS idx_payouts_fee ON payouts(investor_id, period_date)
+WHERE type = 'fee';
@@ -0,0 +1,48 @@
+-- 013_payout_schedules.sql
+-- Регулярные операции: пополнение / снятие каждый месяц в заданный день.
+-- Планировщик в сервере создаёт payouts; schedule_runs не даёт провести
+-- одну и ту же дату дважды (в том числе после перезапуска).
+
+CREATE TABLE IF NOT EXISTS payout_schedules (
+    id SERIAL PRIMARY KEY,
+    investor_id INT NOT NULL REFERENCES investors(id) ON DELETE CASCADE,
+
+    type TEXT NOT NULL CHECK (type IN ('topup', 'profit_withdrawal', 'capital_withdrawal')),
+
+    -- fixed — сумма amount; net_profit — вся накопленная чистая прибыль
+    rule TEXT NOT NULL DEFAULT 'fixed' CHECK (rule IN ('fixed', 'net_profit')),
+    amount NUMERIC(18,2),
+
+    -- 29–31 в коротких месяцах — последний день месяца
+    day_of_month INT NOT NULL CHECK (day_of_month BETWEEN 1 AND 31),
+    start_date DATE NOT NULL,
+    end_date DATE,
+
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+
+    created_by INT REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT payout_schedules_amount_check CHECK (
+        rule = 'fixed' AND amount > 0
+        OR rule = 'net_profit' AND amount IS NULL AND type = 'profit_withdrawal'
+    ),
+    CONSTRAINT payout_schedules_dates_check CHECK (end_date IS NULL OR end_date >= start_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_payout_schedules_investor ON payout_schedules(investor_id);
+
+CREATE TABLE IF NOT EXISTS schedule_runs (
+    id SERIAL PRIMARY KEY,
+    schedule_id INT NOT NULL REFERENCES payout_schedules(id) ON DELETE CASCADE,
+    due_date DATE NOT NULL,
+
+    -- posted — выплата создана; skipped — нечего проводить или отказ проверки
+    status TEXT NOT NULL CHECK (status IN ('posted', 'skipped')),
+    payout_id INT REFERENCES payouts(id) ON DELETE SET NULL,
+    message TEXT,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    UNIQUE (schedule_id, due_date)
+);
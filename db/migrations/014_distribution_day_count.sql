@@ -0,0 +1,7 @@
+-- 014_distribution_day_count.sql
+-- База распределения: NULL — капитал на дату (как раньше),
+-- иначе — средний капитал за месяц по соглашению о днях.
+
+ALTER TABLE distributions
+ADD COLUMN IF NOT EXISTS day_count TEXT
+CHECK (day_count IN ('act/act', '30/360'));
@@ -0,0 +1,20 @@
+-- 015_withholding_tax.sql
+-- Удержание НДФЛ с прибыли. Ставка задаётся по инвестору (0 — не удерживать),
+-- tax_resident — для отчётов. При реинвесте или выводе прибыли создаётся
+-- связанная строка type='tax' с tax_of = id исходной выплаты.
+
+ALTER TABLE investors
+ADD COLUMN IF NOT EXISTS tax_rate NUMERIC(5,2) NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS tax_resident BOOLEAN NOT NULL DEFAULT TRUE;
+
+ALTER TABLE investors
+ADD CONSTRAINT investors_tax_rate_check CHECK (tax_rate BETWEEN 0 AND 100);
+
+ALTER TABLE payouts
+ADD COLUMN IF NOT EXISTS tax_of INT REFERENCES payouts(id) ON DELETE CASCADE;
+
+ALTER TABLE payouts
+ADD CONSTRAINT payouts_tax_of_check CHECK (tax_of IS NULL OR type = 'tax');
+
+-- не больше одного удержания на выплату
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payouts_tax_of ON payouts(tax_of);
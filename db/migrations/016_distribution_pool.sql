@@ -0,0 +1,8 @@
+-- 016_distribution_pool.sql
+-- Распределение прибыли фонда, заданной суммой (пулом), по капиталу.
+-- gross_percent для таких запусков — расчётный: пул / капитал × 100.
+-- house_amount — часть прибыли, оставшаяся управляющему (1 − profit_share).
+
+ALTER TABLE distributions
+ADD COLUMN IF NOT EXISTS pool_amount NUMERIC(18,2),
+ADD COLUMN IF NOT EXISTS house_amount NUMERIC(18,2) NOT NULL DEFAULT 0;
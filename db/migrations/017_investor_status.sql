@@ -0,0 +1,18 @@
+-- 017_investor_status.sql
+-- Статус инвестора вместо физического удаления.
+-- DELETE /api/investors/{id} переводит в archived; строка и выплаты
+-- удаляются только через POST /api/investors/{id}/purge (админ).
+
+ALTER TABLE investors
+ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'active',
+ADD COLUMN IF NOT EXISTS closed_at DATE;
+
+ALTER TABLE investors
+ADD CONSTRAINT investors_status_check
+CHECK (status IN ('active', 'suspended', 'closed', 'archived'));
+
+ALTER TABLE investors
+ADD CONSTRAINT investors_closed_at_check
+CHECK ((closed_at IS NOT NULL) = (status IN ('closed', 'archived')));
+
+CREATE INDEX IF NOT EXISTS idx_investors_status ON investors(status);
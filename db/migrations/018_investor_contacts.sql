@@ -0,0 +1,14 @@
+-- 018_investor_contacts.sql
+-- Контакты, заметки и теги инвестора.
+-- Пустая строка — «не указано»; теги в нижнем регистре, без повторов.
+
+ALTER TABLE investors
+ADD COLUMN IF NOT EXISTS phone TEXT NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS email TEXT NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS telegram TEXT NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS address TEXT NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS notes TEXT NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+
+-- фильтр ?tag= — tags @> ARRAY[...]
+CREATE INDEX IF NOT EXISTS idx_investors_tags ON investors USING GIN (tags);
@@ -0,0 +1,85 @@
+-- 019_funds.sql
+-- Несколько фондов. Инвестор участвует в фонде через позицию
+-- (вложенная сумма, доля прибыли, high-water mark); каждая операция
+-- относится к позиции. Существующие данные переносятся в фонд по умолчанию.
+
+CREATE TABLE IF NOT EXISTS funds (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- фонд по умолчанию ровно один: туда попадает всё без fundId
+CREATE UNIQUE INDEX IF NOT EXISTS idx_funds_default ON funds(is_default) WHERE is_default;
+
+INSERT INTO funds (name, is_default)
+VALUES ('Основной фонд', TRUE)
+ON CONFLICT DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS positions (
+    id SERIAL PRIMARY KEY,
+    fund_id INT NOT NULL REFERENCES funds(id),
+    investor_id INT NOT NULL REFERENCES investors(id) ON DELETE CASCADE,
+
+    invested_amount NUMERIC(18,2) NOT NULL DEFAULT 0,
+    profit_share NUMERIC(5,2) NOT NULL DEFAULT 50,
+    high_water_mark NUMERIC(18,2) NOT NULL DEFAULT 0,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    UNIQUE (fund_id, investor_id),
+    -- для составного FK из payouts: позиция того же инвестора
+    UNIQUE (id, investor_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_positions_investor ON positions(investor_id);
+
+-- условия инвестора становятся его позицией в фонде по умолчанию
+INSERT INTO positions (fund_id, investor_id, invested_amount, profit_share, high_water_mark)
+SELECT f.id, i.id, i.invested_amount, i.profit_share, i.high_water_mark
+FROM investors i
+CROSS JOIN funds f
+WHERE f.is_default
+ON CONFLICT (fund_id, investor_id) DO NOTHING;
+
+-- сумма вложений и HWM теперь только в позициях;
+-- investors.profit_share остаётся долей по умолчанию для новых позиций
+ALTER TABLE investors
+DROP COLUMN IF EXISTS invested_amount,
+DROP COLUMN IF EXISTS high_water_mark;
+
+ALTER TABLE payouts
+ADD COLUMN IF NOT EXISTS position_id INT;
+
+UPDATE payouts p
+SET position_id = pos.id
+FROM positions pos
+JOIN funds f ON f.id = pos.fund_id AND f.is_default
+WHERE pos.investor_id = p.investor_id
+  AND p.position_id IS NULL;
+
+ALTER TABLE payouts
+ALTER COLUMN position_id SET NOT NULL;
+
+ALTER TABLE payouts
+ADD CONSTRAINT payouts_position_fkey
+FOREIGN KEY (position_id, investor_id) REFERENCES positions(id, investor_id);
+
+CREATE INDEX IF NOT EXISTS idx_payouts_position ON payouts(position_id, period_date);
+
+-- распределение и расписание относятся к одному фонду
+ALTER TABLE distributions
+ADD COLUMN IF NOT EXISTS fund_id INT REFERENCES funds(id);
+
+ALTER TABLE payout_schedules
+ADD COLUMN IF NOT EXISTS fund_id INT REFERENCES funds(id);
+
+UPDATE distributions SET fund_id = (SELECT id FROM funds WHERE is_default)
+WHERE fund_id IS NULL;
+
+UPDATE payout_schedules SET fund_id = (SELECT id FROM funds WHERE is_default)
+WHERE fund_id IS NULL;
+
+ALTER TABLE distributions ALTER COLUMN fund_id SET NOT NULL;
+ALTER TABLE payout_schedules ALTER COLUMN fund_id SET NOT NULL;
@@ -0,0 +1,22 @@
+-- 020_profit_share_terms.sql
+-- История доли прибыли по позициям. Доля действует с effective_from до
+-- следующей записи; самая ранняя запись действует и для всего, что раньше неё.
+-- positions.profit_share — доля, действующая сегодня (кэш последней записи).
+
+CREATE TABLE IF NOT EXISTS profit_share_terms (
+    id SERIAL PRIMARY KEY,
+    position_id INT NOT NULL REFERENCES positions(id) ON DELETE CASCADE,
+    effective_from DATE NOT NULL,
+    profit_share NUMERIC(5,2) NOT NULL CHECK (profit_share > 0 AND profit_share <= 100),
+
+    created_by INT REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    UNIQUE (position_id, effective_from)
+);
+
+-- текущие условия становятся первой записью истории
+INSERT INTO profit_share_terms (position_id, effective_from, profit_share)
+SELECT id, created_at::date, profit_share
+FROM positions
+ON CONFLICT (position_id, effective_from) DO NOTHING;
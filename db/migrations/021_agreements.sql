@@ -0,0 +1,51 @@
+-- 021_agreements.sql
+-- Договоры инвестирования: срок, lock-up, уведомление о выводе и штраф
+-- за досрочное снятие капитала. При снятии капитала до lockup_until
+-- создаётся связанная строка type='fee', fee_kind='early_withdrawal'
+-- с penalty_of = id снятия.
+
+CREATE TABLE IF NOT EXISTS agreements (
+    id SERIAL PRIMARY KEY,
+    investor_id INT NOT NULL REFERENCES investors(id) ON DELETE CASCADE,
+    number TEXT NOT NULL UNIQUE,
+
+    start_date DATE NOT NULL,
+    end_date DATE,         -- NULL — бессрочный
+    lockup_until DATE,     -- NULL — без lock-up
+    notice_days INT NOT NULL DEFAULT 0 CHECK (notice_days >= 0),
+    penalty_percent NUMERIC(5,2) NOT NULL DEFAULT 0
+        CHECK (penalty_percent BETWEEN 0 AND 100),
+
+    created_by INT REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CHECK (end_date IS NULL OR end_date >= start_date),
+    CHECK (lockup_until IS NULL OR lockup_until >= start_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_agreements_investor ON agreements(investor_id, start_date);
+
+-- когда инвестор уведомил о снятии капитала
+ALTER TABLE payouts
+ADD COLUMN IF NOT EXISTS notice_date DATE;
+
+ALTER TABLE payouts
+ADD COLUMN IF NOT EXISTS penalty_of INT REFERENCES payouts(id) ON DELETE CASCADE;
+
+ALTER TABLE payouts
+DROP CONSTRAINT IF EXISTS payouts_fee_kind_check;
+
+ALTER TABLE payouts
+ADD CONSTRAINT payouts_fee_kind_check CHECK (
+    fee_kind IN ('management', 'performance', 'early_withdrawal')
+    AND type = 'fee'
+    OR fee_kind IS NULL AND type <> 'fee'
+);
+
+ALTER TABLE payouts
+ADD CONSTRAINT payouts_penalty_of_check CHECK (
+    penalty_of IS NULL OR type = 'fee' AND fee_kind = 'early_withdrawal'
+);
+
+-- не больше одного штрафа на снятие
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payouts_penalty_of ON payouts(penalty_of);
@@ -0,0 +1,8 @@
+-- 022_distribution_unique_month.sql
+-- Не больше одного действующего распределения на фонд за месяц:
+-- повторный запуск выплатил бы всем второй раз. Откатанные не мешают.
+-- Если в базе уже есть дубли, лишние нужно откатить до миграции.
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_distributions_fund_month
+ON distributions (fund_id, (date_trunc('month', period_date::timestamp)))
+WHERE rolled_back_at IS NULL;
@@ -0,0 +1,77 @@
+-- 023_fund_scoped_units.sql
+-- Стоимость пая, паи инвесторов, договоры и отчётные периоды — у каждого
+-- фонда свои. Существующие строки относятся к фонду по умолчанию;
+-- закрытые месяцы остаются закрытыми во всех фондах.
+
+-- стоимость пая
+ALTER TABLE fund_nav
+ADD COLUMN IF NOT EXISTS fund_id INT REFERENCES funds(id);
+
+UPDATE fund_nav SET fund_id = (SELECT id FROM funds WHERE is_default)
+WHERE fund_id IS NULL;
+
+ALTER TABLE fund_nav
+ALTER COLUMN fund_id SET NOT NULL;
+
+ALTER TABLE fund_nav
+DROP CONSTRAINT IF EXISTS fund_nav_nav_date_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_fund_nav_fund_date ON fund_nav(fund_id, nav_date);
+
+-- паи: фонд операции, стартовые выдачи — фонд по умолчанию
+ALTER TABLE investor_units
+ADD COLUMN IF NOT EXISTS fund_id INT REFERENCES funds(id);
+
+UPDATE investor_units u
+SET fund_id = pos.fund_id
+FROM payouts p
+JOIN positions pos ON pos.id = p.position_id
+WHERE p.id = u.payout_id
+  AND u.fund_id IS NULL;
+
+UPDATE investor_units SET fund_id = (SELECT id FROM funds WHERE is_default)
+WHERE fund_id IS NULL;
+
+ALTER TABLE investor_units
+ALTER COLUMN fund_id SET NOT NULL;
+
+DROP INDEX IF EXISTS idx_investor_units_investor;
+CREATE INDEX IF NOT EXISTS idx_investor_units_fund ON investor_units(fund_id, investor_id, trade_date);
+
+-- договоры
+ALTER TABLE agreements
+ADD COLUMN IF NOT EXISTS fund_id INT REFERENCES funds(id);
+
+UPDATE agreements SET fund_id = (SELECT id FROM funds WHERE is_default)
+WHERE fund_id IS NULL;
+
+ALTER TABLE agreements
+ALTER COLUMN fund_id SET NOT NULL;
+
+DROP INDEX IF EXISTS idx_agreements_investor;
+CREATE INDEX IF NOT EXISTS idx_agreements_investor ON agreements(investor_id, fund_id, start_date);
+
+-- отчётные периоды
+ALTER TABLE accounting_periods
+ADD COLUMN IF NOT EXISTS fund_id INT REFERENCES funds(id);
+
+UPDATE accounting_periods SET fund_id = (SELECT id FROM funds WHERE is_default)
+WHERE fund_id IS NULL;
+
+ALTER TABLE accounting_periods
+DROP CONSTRAINT IF EXISTS accounting_periods_pkey;
+
+INSERT INTO accounting_periods (
+    fund_id, period_month, is_closed, closed_at, closed_by, reopened_at, reopened_by
+)
+SELECT f.id, ap.period_month, ap.is_closed, ap.closed_at, ap.closed_by, ap.reopened_at, ap.reopened_by
+FROM accounting_periods ap
+JOIN funds d ON d.id = ap.fund_id AND d.is_default
+CROSS JOIN funds f
+WHERE NOT f.is_default;
+
+ALTER TABLE accounting_periods
+ALTER COLUMN fund_id SET NOT NULL;
+
+ALTER TABLE accounting_periods
+ADD PRIMARY KEY (fund_id, period_month);
@@ -0,0 +1,22 @@
+-- 024_invested_amount_terms.sql
+-- История суммы вложений по позициям, как profit_share_terms: сумма действует
+-- с effective_from до следующей записи; самая ранняя — и для всего, что раньше.
+-- positions.invested_amount — сумма, действующая сегодня (кэш последней записи).
+
+CREATE TABLE IF NOT EXISTS invested_amount_terms (
+    id SERIAL PRIMARY KEY,
+    position_id INT NOT NULL REFERENCES positions(id) ON DELETE CASCADE,
+    effective_from DATE NOT NULL,
+    invested_amount NUMERIC(18,2) NOT NULL CHECK (invested_amount >= 0),
+
+    created_by INT REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    UNIQUE (position_id, effective_from)
+);
+
+-- текущие суммы становятся первой записью истории
+INSERT INTO invested_amount_terms (position_id, effective_from, invested_amount)
+SELECT id, created_at::date, invested_amount
+FROM positions
+ON CONFLICT (position_id, effective_from) DO NOTHING;
@@ -0,0 +1,7 @@
+-- 025_fee_high_water_mark.sql
+-- Performance-комиссия запоминает high-water mark позиции, действовавший
+-- до неё: при сторно или удалении комиссии отметка возвращается к нему.
+-- У старых строк отметки нет — их отмена high-water mark не меняет.
+
+ALTER TABLE payouts
+ADD COLUMN IF NOT EXISTS prev_high_water_mark NUMERIC(18,2);
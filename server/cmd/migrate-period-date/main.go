@@ -0,0 +1,121 @@
+// Одноразовая миграция: переносит period_month в period_date,
+// сообщает о строках без даты и делает period_date NOT NULL.
+//
+//	go run ./cmd/migrate-period-date            — выполнить
+//	go run ./cmd/migrate-period-date -dry-run   — только отчёт, без изменений
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"invest/internal/config"
+	"invest/internal/db"
+	"log"
+	"os"
+	"time"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "только показать, что будет сделано")
+	useCreatedAt := flag.Bool("fallback-created-at", false,
+		"строкам без period_month и period_date взять дату из created_at")
+	flag.Parse()
+
+	cfg := config.Load()
+	pg := db.NewPostgres(cfg)
+	defer pg.Close()
+
+	ctx := context.Background()
+
+	tx, err := pg.BeginTx(ctx, nil)
+	if err != nil {
+		log.Fatalf("❌ begin: %v", err)
+	}
+	defer tx.Rollback()
+
+	// 1. переносим period_month → period_date
+	res, err := tx.ExecContext(ctx,
+		`UPDATE payouts
+         SET period_date = period_month
+         WHERE period_date IS NULL AND period_month IS NOT NULL`)
+	if err != nil {
+		log.Fatalf("❌ backfill: %v", err)
+	}
+	moved, _ := res.RowsAffected()
+	log.Printf("period_month → period_date: %d rows", moved)
+
+	// 2. строки, у которых нет ни одной даты
+	missing, err := rowsWithoutDate(ctx, tx)
+	if err != nil {
+		log.Fatalf("❌ report: %v", err)
+	}
+
+	if len(missing) > 0 && *useCreatedAt {
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE payouts SET period_date = created_at::date
+             WHERE period_date IS NULL`); err != nil {
+			log.Fatalf("❌ fallback: %v", err)
+		}
+		log.Printf("period_date взят из created_at для %d rows", len(missing))
+		missing = nil
+	}
+
+	if len(missing) > 0 {
+		log.Printf("❌ %d rows cannot be converted (no period_month, no period_date):", len(missing))
+		for _, m := range missing {
+			log.Printf("   payout id=%d investor_id=%d created_at=%s",
+				m.id, m.investorID, m.createdAt.Format(time.RFC3339))
+		}
+		log.Printf("Исправьте даты вручную или запустите с -fallback-created-at")
+	} else {
+		// 3. теперь дата обязательна
+		if _, err := tx.ExecContext(ctx,
+			`ALTER TABLE payouts ALTER COLUMN period_date SET NOT NULL`); err != nil {
+			log.Fatalf("❌ set not null: %v", err)
+		}
+		log.Println("period_date is NOT NULL")
+	}
+
+	if *dryRun {
+		log.Println("dry run: изменения не сохранены")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Fatalf("❌ commit: %v", err)
+	}
+
+	if len(missing) > 0 {
+		os.Exit(1)
+	}
+	log.Println("✅ done")
+}
+
+type missingRow struct {
+	id         int64
+	investorID int64
+	createdAt  time.Time
+}
+
+func rowsWithoutDate(ctx context.Context, tx *sql.Tx) ([]missingRow, error) {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT id, investor_id, created_at
+         FROM payouts
+         WHERE period_date IS NULL
+         ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []missingRow
+	for rows.Next() {
+		var m missingRow
+		if err := rows.Scan(&m.id, &m.investorID, &m.createdAt); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"invest/internal/config"
 	"invest/internal/db"
 	"invest/internal/repository"
+	"invest/internal/scheduler"
 	httpHandlers "invest/internal/http"
 	"log"
 	"net/http"
@@ -13,6 +15,12 @@ func main() {
 	cfg := config.Load()          // Загружаем переменные окружения
 	pg := db.NewPostgres(cfg)     // Коннект к PostgreSQL
 	repo := repository.New(pg)    // Инициализация репозитория
+	repo.SetUnitMode(cfg.FundMode == "units")
+
+	// регулярные операции проводятся в фоне, в том же процессе
+	if cfg.SchedulerInterval > 0 {
+		go scheduler.New(repo, cfg.SchedulerInterval).Run(context.Background())
+	}
 
 	// Создаём HTTP-сервер с репозиторием и конфигом
 	srv := httpHandlers.NewServer(repo, cfg)
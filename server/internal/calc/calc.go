@@ -0,0 +1,161 @@
+package calc
+
+import (
+	"invest/internal/models"
+	"time"
+)
+
+// ========================
+//        SUMMARY
+// ========================
+
+// Summary — итоговые показатели инвестора.
+// Формулы повторяют клиентский useInvestData.js, чтобы цифры совпадали.
+type Summary struct {
+	InvestorID         int64          `json:"investor_id"`
+	AsOf               *time.Time     `json:"as_of,omitempty"` // баланс на дату; nil — по всем операциям
+	InvestedAmount     models.Decimal `json:"invested_amount"`
+	ReinvestedTotal    models.Decimal `json:"reinvested_total"`
+	TopupsTotal        models.Decimal `json:"topups_total"`
+	AdjustmentsTotal   models.Decimal `json:"adjustments_total"`
+	WithdrawnCapital   models.Decimal `json:"withdrawn_capital"`
+	WithdrawnProfit    models.Decimal `json:"withdrawn_profit"`
+	CapitalNow         models.Decimal `json:"capital_now"`
+	NetProfitNow       models.Decimal `json:"net_profit_now"`
+	TotalProfitAllTime models.Decimal `json:"total_profit_all_time"` // gross, до комиссий
+
+	// комиссии управляющего и штрафы за досрочное снятие (вместе — FeesTotal);
+	// прибыль — за вычетом только комиссий
+	ManagementFees     models.Decimal `json:"management_fees"`
+	PerformanceFees    models.Decimal `json:"performance_fees"`
+	Penalties          models.Decimal `json:"penalties"`
+	FeesTotal          models.Decimal `json:"fees_total"`
+	NetProfitAfterFees models.Decimal `json:"net_profit_after_fees"`
+
+	// удержанный налог: gross → налог → net
+	TaxWithheld        models.Decimal `json:"tax_withheld"`
+	WithdrawnProfitNet models.Decimal `json:"withdrawn_profit_net"` // выведено на руки после налога
+	NetProfitAfterTax  models.Decimal `json:"net_profit_after_tax"`
+}
+
+// Summarize считает показатели одного инвестора.
+// payouts могут содержать строки других инвесторов — они пропускаются.
+func Summarize(inv models.Investor, payouts []models.Payout) Summary {
+	s := Summary{
+		InvestorID:     inv.ID,
+		InvestedAmount: inv.InvestedAmount,
+	}
+
+	var net, taxOnWithdrawals models.Decimal
+
+	for _, p := range Active(payouts) {
+		if p.InvestorID != inv.ID {
+			continue
+		}
+
+		amount := p.PayoutAmount
+
+		switch p.Kind() {
+		case models.PayoutTopup:
+			s.TopupsTotal = s.TopupsTotal.Add(amount)
+
+		case models.PayoutCapitalWithdrawal:
+			s.WithdrawnCapital = s.WithdrawnCapital.Add(amount.Abs())
+
+		case models.PayoutReinvest:
+			s.ReinvestedTotal = s.ReinvestedTotal.Add(amount)
+			s.TotalProfitAllTime = s.TotalProfitAllTime.Add(amount.Abs())
+			net = net.Add(amount)
+
+		case models.PayoutProfitWithdrawal:
+			s.WithdrawnProfit = s.WithdrawnProfit.Add(amount.Abs())
+			s.TotalProfitAllTime = s.TotalProfitAllTime.Add(amount.Abs())
+			net = net.Sub(amount.Abs())
+
+		case models.PayoutAdjustment:
+			// корректировка капитала, знак задаёт оператор
+			s.AdjustmentsTotal = s.AdjustmentsTotal.Add(amount)
+
+		case models.PayoutFee:
+			// комиссия списывается из капитала за счёт накопленной прибыли;
+			// штраф за досрочное снятие — только из капитала: он не расход
+			// на управление, и чистую прибыль не уменьшает
+			switch {
+			case p.FeeKind != nil && *p.FeeKind == models.FeePerformance:
+				s.PerformanceFees = s.PerformanceFees.Add(amount.Abs())
+				net = net.Sub(amount.Abs())
+			case p.FeeKind != nil && *p.FeeKind == models.FeeEarlyWithdrawal:
+				s.Penalties = s.Penalties.Add(amount.Abs())
+			default:
+				s.ManagementFees = s.ManagementFees.Add(amount.Abs())
+				net = net.Sub(amount.Abs())
+			}
+
+		case models.PayoutTax:
+			s.TaxWithheld = s.TaxWithheld.Add(amount.Abs())
+			if p.TaxOnWithdrawal {
+				taxOnWithdrawals = taxOnWithdrawals.Add(amount.Abs())
+			} else {
+				// налог с реинвеста списывается из капитала
+				net = net.Sub(amount.Abs())
+			}
+		}
+	}
+
+	s.FeesTotal = s.ManagementFees.Add(s.PerformanceFees).Add(s.Penalties)
+	s.NetProfitAfterFees = s.TotalProfitAllTime.Sub(s.ManagementFees).Sub(s.PerformanceFees)
+	s.NetProfitAfterTax = s.NetProfitAfterFees.Sub(s.TaxWithheld)
+	s.WithdrawnProfitNet = s.WithdrawnProfit.Sub(taxOnWithdrawals)
+
+	// капитал сейчас = база + реинвесты + пополнения - снятия капитала (+ корректировки)
+	// - комиссии - налог с реинвестов
+	s.CapitalNow = s.InvestedAmount.
+		Add(s.ReinvestedTotal).
+		Add(s.TopupsTotal).
+		Sub(s.WithdrawnCapital).
+		Add(s.AdjustmentsTotal).
+		Sub(s.FeesTotal).
+		Sub(s.TaxWithheld.Sub(taxOnWithdrawals))
+
+	// чистая прибыль не бывает отрицательной
+	s.NetProfitNow = models.MaxDecimal(net, models.Decimal{})
+
+	return s
+}
+
+// SummarizeAll считает показатели для списка инвесторов за один проход по выплатам.
+func SummarizeAll(investors []models.Investor, payouts []models.Payout) []Summary {
+	byInvestor := make(map[int64][]models.Payout, len(investors))
+	for _, p := range payouts {
+		byInvestor[p.InvestorID] = append(byInvestor[p.InvestorID], p)
+	}
+
+	out := make([]Summary, 0, len(investors))
+	for _, inv := range investors {
+		out = append(out, Summarize(inv, byInvestor[inv.ID]))
+	}
+	return out
+}
+
+// Active убирает отменённые пары: исходную запись и её сторно.
+// Обе остаются в истории, но в расчётах не участвуют.
+func Active(payouts []models.Payout) []models.Payout {
+	reversed := make(map[int64]bool)
+	for _, p := range payouts {
+		if p.ReversalOf != nil {
+			reversed[*p.ReversalOf] = true
+		}
+	}
+	if len(reversed) == 0 {
+		return payouts
+	}
+
+	out := make([]models.Payout, 0, len(payouts))
+	for _, p := range payouts {
+		if p.ReversalOf != nil || reversed[p.ID] {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
@@ -0,0 +1,42 @@
+package calc
+
+import (
+	"invest/internal/models"
+	"testing"
+)
+
+func TestSummarizeFeesAndPenalties(t *testing.T) {
+	management := models.FeeManagement
+	penalty := models.FeeEarlyWithdrawal
+	withdrawal := int64(3)
+
+	inv := models.Investor{ID: 1, InvestedAmount: models.DecimalFromInt(100000)}
+	payouts := []models.Payout{
+		{ID: 1, InvestorID: 1, PeriodDate: date("2024-01-31"), PayoutAmount: models.DecimalFromInt(5000), Type: models.PayoutReinvest},
+		{ID: 2, InvestorID: 1, PeriodDate: date("2024-01-31"), PayoutAmount: models.DecimalFromInt(200), Type: models.PayoutFee, FeeKind: &management},
+		{ID: 3, InvestorID: 1, PeriodDate: date("2024-02-10"), PayoutAmount: models.DecimalFromInt(-10000), Type: models.PayoutCapitalWithdrawal},
+		{ID: 4, InvestorID: 1, PeriodDate: date("2024-02-10"), PayoutAmount: models.DecimalFromInt(500), Type: models.PayoutFee, FeeKind: &penalty, PenaltyOf: &withdrawal},
+	}
+
+	s := Summarize(inv, payouts)
+
+	tests := []struct {
+		name string
+		got  models.Decimal
+		want string
+	}{
+		// 100000 + 5000 − 10000 − 200 − 500
+		{"CapitalNow", s.CapitalNow, "94300.00"},
+		{"FeesTotal", s.FeesTotal, "700.00"},
+		{"Penalties", s.Penalties, "500.00"},
+		// штраф чистую прибыль не уменьшает
+		{"NetProfitNow", s.NetProfitNow, "4800.00"},
+		{"NetProfitAfterFees", s.NetProfitAfterFees, "4800.00"},
+	}
+
+	for _, tt := range tests {
+		if tt.got.String() != tt.want {
+			t.Errorf("%s = %s, want %s", tt.name, tt.got, tt.want)
+		}
+	}
+}
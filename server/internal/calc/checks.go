@@ -0,0 +1,52 @@
+package calc
+
+import (
+	"errors"
+	"fmt"
+	"invest/internal/models"
+)
+
+// ========================
+//      ПРОВЕРКИ СНЯТИЙ
+// ========================
+
+var (
+	ErrCapitalExceeded = errors.New("withdrawal exceeds current capital")
+	ErrProfitExceeded  = errors.New("withdrawal exceeds accumulated net profit")
+)
+
+// LimitError — снятие больше доступного; errors.Is работает с Err
+type LimitError struct {
+	Err       error
+	Requested models.Decimal
+	Available models.Decimal
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s: requested %s, available %s", e.Err, e.Requested, e.Available)
+}
+
+func (e *LimitError) Unwrap() error { return e.Err }
+
+// CheckWithdrawal проверяет, что снятие капитала не превышает текущий капитал
+// (база + реинвесты + пополнения − снятия), а вывод прибыли — накопленную
+// чистую прибыль (реинвестированная и ещё не выведенная, за вычетом комиссий).
+// payouts — уже записанные операции инвестора, без проверяемой p.
+func CheckWithdrawal(inv models.Investor, payouts []models.Payout, p models.Payout) error {
+	s := Summarize(inv, payouts)
+	requested := p.PayoutAmount.Abs()
+
+	switch p.Kind() {
+	case models.PayoutCapitalWithdrawal:
+		if requested.Cmp(s.CapitalNow) > 0 {
+			return &LimitError{Err: ErrCapitalExceeded, Requested: requested, Available: s.CapitalNow}
+		}
+
+	case models.PayoutProfitWithdrawal:
+		if requested.Cmp(s.NetProfitNow) > 0 {
+			return &LimitError{Err: ErrProfitExceeded, Requested: requested, Available: s.NetProfitNow}
+		}
+	}
+
+	return nil
+}
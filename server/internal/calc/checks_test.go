@@ -0,0 +1,82 @@
+package calc
+
+import (
+	"errors"
+	"invest/internal/models"
+	"testing"
+)
+
+func TestCheckWithdrawal(t *testing.T) {
+	inv := models.Investor{ID: 1, InvestedAmount: models.DecimalFromInt(100000)}
+	payouts := []models.Payout{
+		{ID: 1, InvestorID: 1, PeriodDate: date("2024-01-31"), PayoutAmount: models.DecimalFromInt(5000), Type: models.PayoutReinvest},
+		{ID: 2, InvestorID: 1, PeriodDate: date("2024-02-15"), PayoutAmount: models.DecimalFromInt(-20000), Type: models.PayoutCapitalWithdrawal},
+		{ID: 3, InvestorID: 1, PeriodDate: date("2024-02-29"), PayoutAmount: models.DecimalFromInt(3000), Type: models.PayoutReinvest},
+		{ID: 4, InvestorID: 1, PeriodDate: date("2024-03-10"), PayoutAmount: models.DecimalFromInt(2000), Type: models.PayoutProfitWithdrawal},
+	}
+
+	tests := []struct {
+		name    string
+		payouts []models.Payout
+		p       models.Payout
+		wantErr error
+	}{
+		{
+			name:    "снятие всего капитала",
+			payouts: payouts,
+			p:       models.Payout{PeriodDate: date("2024-04-01"), PayoutAmount: models.DecimalFromInt(-88000), Type: models.PayoutCapitalWithdrawal},
+		},
+		{
+			name:    "снятие больше капитала",
+			payouts: payouts,
+			p:       models.Payout{PeriodDate: date("2024-04-01"), PayoutAmount: models.DecimalFromInt(-88001), Type: models.PayoutCapitalWithdrawal},
+			wantErr: ErrCapitalExceeded,
+		},
+		{
+			name:    "вывод остатка прибыли",
+			payouts: payouts,
+			p:       models.Payout{PeriodDate: date("2024-04-01"), PayoutAmount: models.DecimalFromInt(6000), Type: models.PayoutProfitWithdrawal},
+		},
+		{
+			name:    "вывод больше накопленной прибыли",
+			payouts: payouts,
+			p:       models.Payout{PeriodDate: date("2024-04-01"), PayoutAmount: models.DecimalFromInt(6001), Type: models.PayoutProfitWithdrawal},
+			wantErr: ErrProfitExceeded,
+		},
+		{
+			name:    "прибыли нет — капитал не выводится как прибыль",
+			p:       models.Payout{PeriodDate: date("2024-04-01"), PayoutAmount: models.DecimalFromInt(1), Type: models.PayoutProfitWithdrawal},
+			wantErr: ErrProfitExceeded,
+		},
+		{
+			name: "прибыль уже выведена целиком",
+			payouts: append(payouts,
+				models.Payout{ID: 5, InvestorID: 1, PeriodDate: date("2024-03-20"), PayoutAmount: models.DecimalFromInt(6000), Type: models.PayoutProfitWithdrawal},
+			),
+			p:       models.Payout{PeriodDate: date("2024-04-01"), PayoutAmount: models.DecimalFromInt(1), Type: models.PayoutProfitWithdrawal},
+			wantErr: ErrProfitExceeded,
+		},
+		{
+			name:    "реинвест не проверяется",
+			payouts: payouts,
+			p:       models.Payout{PeriodDate: date("2024-04-01"), PayoutAmount: models.DecimalFromInt(1000000), Type: models.PayoutReinvest},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.p.InvestorID = 1
+			err := CheckWithdrawal(inv, tt.payouts, tt.p)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf("err = %v, want nil", err)
+				}
+				return
+			}
+			var le *LimitError
+			if !errors.Is(err, tt.wantErr) || !errors.As(err, &le) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
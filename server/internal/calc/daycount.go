@@ -0,0 +1,161 @@
+package calc
+
+import (
+	"invest/internal/models"
+	"sort"
+	"time"
+)
+
+// ========================
+//   СРЕДНИЙ КАПИТАЛ (PRO-RATA)
+// ========================
+
+// DayCount — соглашение о подсчёте дней
+type DayCount string
+
+const (
+	DayCountActual DayCount = "act/act" // фактические дни периода
+	DayCount30360  DayCount = "30/360"  // 30E/360: каждый месяц — 30 дней, 31-е число считается 30-м
+)
+
+func (c DayCount) Valid() bool {
+	switch c {
+	case DayCountActual, DayCount30360:
+		return true
+	}
+	return false
+}
+
+// Days — число дней между from и to (to не включается)
+func (c DayCount) Days(from, to time.Time) int {
+	from, to = truncateDay(from), truncateDay(to)
+
+	if c == DayCount30360 {
+		d1, d2 := from.Day(), to.Day()
+		if d1 == 31 {
+			d1 = 30
+		}
+		if d2 == 31 {
+			d2 = 30
+		}
+		return 360*(to.Year()-from.Year()) + 30*(int(to.Month())-int(from.Month())) + d2 - d1
+	}
+
+	return int(to.Sub(from).Hours() / 24)
+}
+
+// CapitalBaseLine — отрезок периода с постоянным капиталом
+type CapitalBaseLine struct {
+	From     time.Time         `json:"from"`
+	To       time.Time         `json:"to"` // включительно
+	Type     models.PayoutType `json:"type,omitempty"`
+	PayoutID int64             `json:"payout_id,omitempty"`
+	Change   models.Decimal    `json:"change"` // движение капитала в начале отрезка
+	Capital  models.Decimal    `json:"capital"`
+	Days     int               `json:"days"`
+	Weighted models.Decimal    `json:"weighted"` // капитал × дни / дни периода
+}
+
+// CapitalBase — средневзвешенный по дням капитал за период [From, To]
+type CapitalBase struct {
+	InvestorID   int64             `json:"investor_id"`
+	From         time.Time         `json:"from"`
+	To           time.Time         `json:"to"`
+	DayCount     DayCount          `json:"day_count"`
+	PeriodDays   int               `json:"period_days"`
+	StartCapital models.Decimal    `json:"start_capital"`
+	EndCapital   models.Decimal    `json:"end_capital"`
+	Average      models.Decimal    `json:"average_capital"`
+	Lines        []CapitalBaseLine `json:"lines"`
+}
+
+// AverageCapital считает капитал, взвешенный по дням, за период [from, to].
+// Операции до from дают капитал на начало; операции внутри периода
+// (пополнения, снятия капитала и прочие движения) действуют с дня period_date.
+// Операции после to не учитываются.
+func AverageCapital(inv models.Investor, payouts []models.Payout, from, to time.Time, conv DayCount) CapitalBase {
+	from, to = truncateDay(from), truncateDay(to)
+	end := to.AddDate(0, 0, 1)
+
+	base := CapitalBase{
+		InvestorID: inv.ID,
+		From:       from,
+		To:         to,
+		DayCount:   conv,
+		PeriodDays: conv.Days(from, end),
+	}
+
+	own := make([]models.Payout, 0, len(payouts))
+	for _, p := range Active(payouts) {
+		if p.InvestorID == inv.ID {
+			own = append(own, p)
+		}
+	}
+	sort.SliceStable(own, func(i, j int) bool {
+		return own[i].PeriodDate.Before(own[j].PeriodDate)
+	})
+
+	capital := inv.InvestedAmount
+	i := 0
+	for ; i < len(own) && own[i].PeriodDate.Before(from); i++ {
+		capital = capital.Add(capitalDelta(own[i]))
+	}
+	base.StartCapital = capital
+
+	line := CapitalBaseLine{From: from, Capital: capital}
+
+	// закрывает текущий отрезок перед датой next
+	closeLine := func(next time.Time) {
+		line.To = next.AddDate(0, 0, -1)
+		line.Days = conv.Days(line.From, next)
+		if base.PeriodDays > 0 {
+			line.Weighted = line.Capital.MulRatio(
+				models.DecimalFromInt(int64(line.Days)), models.DecimalFromInt(int64(base.PeriodDays)))
+		}
+		base.Lines = append(base.Lines, line)
+	}
+
+	for ; i < len(own) && !own[i].PeriodDate.After(to); i++ {
+		p := own[i]
+		delta := capitalDelta(p)
+		if delta.IsZero() {
+			continue
+		}
+
+		date := truncateDay(p.PeriodDate)
+		if date.After(line.From) {
+			closeLine(date)
+			line = CapitalBaseLine{From: date, Capital: capital}
+		}
+
+		// несколько операций в один день — один отрезок, последняя операция в описании
+		capital = capital.Add(delta)
+		line.Capital = capital
+		line.Change = line.Change.Add(delta)
+		line.Type = p.Kind()
+		line.PayoutID = p.ID
+	}
+	closeLine(end)
+
+	base.EndCapital = capital
+
+	// среднее округляется один раз от точной суммы капитал × дни:
+	// сумма округлённых Weighted может разойтись с ним на копейки
+	if base.PeriodDays > 0 {
+		var total models.Decimal
+		for _, l := range base.Lines {
+			total = total.Add(l.Capital.MulRatio(
+				models.DecimalFromInt(int64(l.Days)), models.DecimalFromInt(1)))
+		}
+		base.Average = total.MulRatio(
+			models.DecimalFromInt(1), models.DecimalFromInt(int64(base.PeriodDays)))
+	}
+
+	return base
+}
+
+// MonthBounds — первый и последний день месяца даты
+func MonthBounds(date time.Time) (time.Time, time.Time) {
+	start := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, -1)
+}
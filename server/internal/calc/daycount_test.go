@@ -0,0 +1,107 @@
+package calc
+
+import (
+	"invest/internal/models"
+	"testing"
+)
+
+func TestDayCountDays(t *testing.T) {
+	tests := []struct {
+		conv     DayCount
+		from, to string
+		want     int
+	}{
+		{DayCountActual, "2024-01-01", "2024-02-01", 31},
+		{DayCountActual, "2024-02-01", "2024-03-01", 29}, // високосный
+		{DayCountActual, "2023-02-01", "2023-03-01", 28},
+		{DayCountActual, "2024-01-01", "2025-01-01", 366},
+		{DayCountActual, "2024-03-15", "2024-03-15", 0},
+
+		{DayCount30360, "2024-01-01", "2024-02-01", 30},
+		{DayCount30360, "2024-02-01", "2024-03-01", 30},
+		{DayCount30360, "2024-01-31", "2024-02-01", 1}, // 31-е считается 30-м
+		{DayCount30360, "2024-01-01", "2024-01-31", 29},
+		{DayCount30360, "2024-01-15", "2024-03-31", 75},
+		{DayCount30360, "2024-01-01", "2025-01-01", 360},
+	}
+
+	for _, tt := range tests {
+		if got := tt.conv.Days(date(tt.from), date(tt.to)); got != tt.want {
+			t.Errorf("%s Days(%s, %s) = %d, want %d", tt.conv, tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestAverageCapital(t *testing.T) {
+	inv := models.Investor{ID: 1, InvestedAmount: models.DecimalFromInt(100000)}
+	payouts := []models.Payout{
+		// до периода — входит в стартовый капитал
+		{ID: 1, InvestorID: 1, PeriodDate: date("2023-12-20"), PayoutAmount: models.DecimalFromInt(10000), Type: models.PayoutTopup},
+		{ID: 2, InvestorID: 1, PeriodDate: date("2024-01-16"), PayoutAmount: models.DecimalFromInt(50000), Type: models.PayoutTopup},
+		// прибыль не двигает капитал внутри месяца
+		{ID: 3, InvestorID: 1, PeriodDate: date("2024-01-20"), PayoutAmount: models.DecimalFromInt(3000), Type: models.PayoutProfitWithdrawal},
+		// после периода — не учитывается
+		{ID: 4, InvestorID: 1, PeriodDate: date("2024-02-01"), PayoutAmount: models.DecimalFromInt(-20000), Type: models.PayoutCapitalWithdrawal},
+		// чужая операция
+		{ID: 5, InvestorID: 2, PeriodDate: date("2024-01-10"), PayoutAmount: models.DecimalFromInt(99999), Type: models.PayoutTopup},
+	}
+
+	tests := []struct {
+		conv        DayCount
+		periodDays  int
+		wantDays    []int
+		wantAverage string
+	}{
+		// 110000 × 15/31 + 160000 × 16/31
+		{DayCountActual, 31, []int{15, 16}, "135806.45"},
+		// 110000 × 15/30 + 160000 × 15/30
+		{DayCount30360, 30, []int{15, 15}, "135000.00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.conv), func(t *testing.T) {
+			base := AverageCapital(inv, payouts, date("2024-01-01"), date("2024-01-31"), tt.conv)
+
+			if base.PeriodDays != tt.periodDays {
+				t.Errorf("PeriodDays = %d, want %d", base.PeriodDays, tt.periodDays)
+			}
+			if got := base.StartCapital.String(); got != "110000.00" {
+				t.Errorf("StartCapital = %s, want 110000.00", got)
+			}
+			if got := base.EndCapital.String(); got != "160000.00" {
+				t.Errorf("EndCapital = %s, want 160000.00", got)
+			}
+			if len(base.Lines) != len(tt.wantDays) {
+				t.Fatalf("lines = %d, want %d", len(base.Lines), len(tt.wantDays))
+			}
+			for i, l := range base.Lines {
+				if l.Days != tt.wantDays[i] {
+					t.Errorf("line %d days = %d, want %d", i, l.Days, tt.wantDays[i])
+				}
+			}
+			if got := base.Average.String(); got != tt.wantAverage {
+				t.Errorf("Average = %s, want %s", got, tt.wantAverage)
+			}
+		})
+	}
+}
+
+func TestAverageCapitalSameDayOperations(t *testing.T) {
+	inv := models.Investor{ID: 1, InvestedAmount: models.DecimalFromInt(1000)}
+	payouts := []models.Payout{
+		{ID: 1, InvestorID: 1, PeriodDate: date("2024-01-01"), PayoutAmount: models.DecimalFromInt(500), Type: models.PayoutTopup},
+		{ID: 2, InvestorID: 1, PeriodDate: date("2024-01-01"), PayoutAmount: models.DecimalFromInt(-300), Type: models.PayoutCapitalWithdrawal},
+	}
+
+	base := AverageCapital(inv, payouts, date("2024-01-01"), date("2024-01-31"), DayCountActual)
+
+	if len(base.Lines) != 1 {
+		t.Fatalf("lines = %d, want 1", len(base.Lines))
+	}
+	if got := base.Lines[0].Change.String(); got != "200.00" {
+		t.Errorf("Change = %s, want 200.00", got)
+	}
+	if got := base.Average.String(); got != "1200.00" {
+		t.Errorf("Average = %s, want 1200.00", got)
+	}
+}
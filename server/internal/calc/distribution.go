@@ -0,0 +1,284 @@
+package calc
+
+import (
+	"errors"
+	"invest/internal/models"
+	"strings"
+	"time"
+)
+
+// ========================
+//      DISTRIBUTION
+// ========================
+
+// ErrPoolNoCapital — пул не на что делить: у участников нет капитала
+var ErrPoolNoCapital = errors.New("pool cannot be distributed: fund has no capital at period start")
+
+type DistributionMode string
+
+const (
+	ModeReinvest DistributionMode = "reinvest"
+	ModeWithdraw DistributionMode = "withdraw"
+	ModeSkip     DistributionMode = "skip"
+)
+
+func (m DistributionMode) Valid() bool {
+	switch m {
+	case ModeReinvest, ModeWithdraw, ModeSkip:
+		return true
+	}
+	return false
+}
+
+// DistributionInput — параметры распределения за месяц
+type DistributionInput struct {
+	PeriodDate   time.Time
+	GrossPercent models.Decimal
+
+	// выбор по инвесторам; кого нет в Choices — получает DefaultMode
+	DefaultMode DistributionMode
+	Choices     map[int64]DistributionMode
+
+	// ProRata — база = средний по дням капитал за месяц PeriodDate
+	// вместо капитала на дату; DayCount — соглашение о днях
+	ProRata  bool
+	DayCount DayCount
+
+	// PoolAmount > 0 — вместо GrossPercent задана прибыль фонда суммой:
+	// она делится по капиталу на начало месяца (или среднему при ProRata)
+	PoolAmount models.Decimal
+}
+
+// DistributionLine — строка расчёта по одному инвестору
+type DistributionLine struct {
+	InvestorID       int64            `json:"investor_id"`
+	FullName         string           `json:"full_name"`
+	Mode             DistributionMode `json:"mode"`
+	CapitalBase      models.Decimal   `json:"capital_base"`
+	ProfitShare      models.Decimal   `json:"profit_share"`
+	EffectivePercent models.Decimal   `json:"effective_percent"`
+	Amount           models.Decimal   `json:"amount"`
+	NewCapital       models.Decimal   `json:"new_capital"`
+
+	// прибыль на капитал инвестора до profit_share и доля управляющего:
+	// GrossAmount = Amount + HouseAmount
+	GrossAmount models.Decimal `json:"gross_amount"`
+	HouseAmount models.Decimal `json:"house_amount"`
+
+	// расшифровка базы при ProRata
+	CapitalBreakdown *CapitalBase `json:"capital_breakdown,omitempty"`
+
+	// предупреждения для предпросмотра: zero_capital, missing_name
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+const (
+	WarningZeroCapital = "zero_capital"
+	WarningMissingName = "missing_name"
+)
+
+type DistributionPlan struct {
+	PeriodDate   time.Time          `json:"period_date"`
+	GrossPercent models.Decimal     `json:"gross_percent"`
+	DayCount     DayCount           `json:"day_count,omitempty"` // пусто — капитал на дату
+	PoolAmount   *models.Decimal    `json:"pool_amount,omitempty"`
+	Lines        []DistributionLine `json:"lines"`
+
+	// итоги по фонду
+	InvestorsCount  int            `json:"investors_count"`
+	TotalCapital    models.Decimal `json:"total_capital"`
+	TotalAmount     models.Decimal `json:"total_amount"`
+	TotalReinvest   models.Decimal `json:"total_reinvest"`
+	TotalWithdraw   models.Decimal `json:"total_withdraw"`
+	TotalNewCapital models.Decimal `json:"total_new_capital"`
+	TotalGross      models.Decimal `json:"total_gross"`
+	TotalHouse      models.Decimal `json:"total_house"` // остаётся управляющему
+	WarningsCount   int            `json:"warnings_count"`
+}
+
+// PlanDistribution считает выплату каждому инвестору так же, как
+// «применить % ко всем» в InvestorsTable.jsx:
+// индивидуальный % = общий % × profit_share / 100,
+// сумма = капитал на начало месяца × индивидуальный % / 100, округлённая до рубля.
+// При ProRata вместо капитала на начало — средний по дням капитал за месяц.
+// Операции после месяца PeriodDate в расчёт не входят: повторный или
+// задним числом расчёт месяца даёт те же суммы, что и в своё время.
+//
+// Режим пула (PoolAmount > 0): прибыль фонда делится по капиталу на начало
+// месяца с точностью до копейки (наибольший остаток, сумма частей = пул),
+// инвестор получает долю × profit_share, остальное — управляющему.
+func PlanDistribution(investors []models.Investor, payouts []models.Payout, in DistributionInput) DistributionPlan {
+	plan := DistributionPlan{
+		PeriodDate:   in.PeriodDate,
+		GrossPercent: in.GrossPercent,
+	}
+	if in.ProRata {
+		plan.DayCount = in.DayCount
+	}
+
+	pool := in.PoolAmount.Sign() > 0
+	if pool {
+		plan.PoolAmount = &in.PoolAmount
+	}
+
+	monthStart, monthEnd := MonthBounds(in.PeriodDate)
+
+	for _, inv := range investors {
+		mode, ok := in.Choices[inv.ID]
+		if !ok {
+			mode = in.DefaultMode
+		}
+
+		line := DistributionLine{
+			InvestorID:  inv.ID,
+			FullName:    inv.FullName,
+			Mode:        mode,
+			CapitalBase: capitalBefore(inv, payouts, monthStart),
+			ProfitShare: inv.ProfitShare,
+			// капитал на конец месяца, до этого распределения
+			NewCapital: capitalBefore(inv, payouts, monthEnd.AddDate(0, 0, 1)),
+		}
+
+		if in.ProRata {
+			breakdown := AverageCapital(inv, payouts, monthStart, monthEnd, in.DayCount)
+			line.CapitalBase = breakdown.Average
+			line.CapitalBreakdown = &breakdown
+		}
+
+		if line.CapitalBase.Sign() <= 0 {
+			line.Warnings = append(line.Warnings, WarningZeroCapital)
+		}
+		if strings.TrimSpace(inv.FullName) == "" {
+			line.Warnings = append(line.Warnings, WarningMissingName)
+		}
+
+		plan.Lines = append(plan.Lines, line)
+	}
+
+	if pool {
+		allocatePool(&plan, in.PoolAmount)
+	}
+
+	for i := range plan.Lines {
+		line := &plan.Lines[i]
+
+		if !pool && line.CapitalBase.Sign() > 0 {
+			line.GrossAmount = line.CapitalBase.MulPercent(in.GrossPercent)
+		}
+
+		if line.Mode != ModeSkip {
+			line.EffectivePercent = plan.GrossPercent.MulPercent(line.ProfitShare)
+
+			if pool {
+				line.Amount = line.GrossAmount.MulPercent(line.ProfitShare)
+			} else {
+				line.Amount = line.CapitalBase.MulPercent(line.EffectivePercent).RoundUnits()
+			}
+
+			// отрицательный капитал не даёт прибыли
+			if line.Amount.Sign() < 0 {
+				line.Amount = models.Decimal{}
+			}
+			// округление до рубля не может отдать инвестору больше прибыли
+			// на его капитал: доля управляющего не уходит в минус
+			if line.Amount.Cmp(line.GrossAmount) > 0 {
+				line.Amount = line.GrossAmount
+			}
+
+			if line.Mode == ModeReinvest {
+				line.NewCapital = line.NewCapital.Add(line.Amount)
+			}
+		}
+
+		// всё, что не досталось инвестору (в т.ч. при skip), остаётся управляющему
+		line.HouseAmount = line.GrossAmount.Sub(line.Amount)
+
+		plan.InvestorsCount++
+		plan.TotalCapital = plan.TotalCapital.Add(line.CapitalBase)
+		plan.TotalAmount = plan.TotalAmount.Add(line.Amount)
+		plan.TotalNewCapital = plan.TotalNewCapital.Add(line.NewCapital)
+		plan.TotalGross = plan.TotalGross.Add(line.GrossAmount)
+		plan.TotalHouse = plan.TotalHouse.Add(line.HouseAmount)
+
+		switch line.Mode {
+		case ModeReinvest:
+			plan.TotalReinvest = plan.TotalReinvest.Add(line.Amount)
+		case ModeWithdraw:
+			plan.TotalWithdraw = plan.TotalWithdraw.Add(line.Amount)
+		}
+
+		if len(line.Warnings) > 0 {
+			plan.WarningsCount++
+		}
+	}
+
+	return plan
+}
+
+// allocatePool делит пул по положительному капиталу строк и выставляет
+// GrossPercent плана как пул / суммарный капитал × 100
+func allocatePool(plan *DistributionPlan, pool models.Decimal) {
+	weights := make([]models.Decimal, len(plan.Lines))
+	var total models.Decimal
+	for i, line := range plan.Lines {
+		if line.CapitalBase.Sign() > 0 {
+			weights[i] = line.CapitalBase
+			total = total.Add(line.CapitalBase)
+		}
+	}
+
+	for i, part := range pool.Allocate(weights) {
+		plan.Lines[i].GrossAmount = part
+	}
+
+	plan.GrossPercent = models.DecimalFromInt(100).MulRatio(pool, total)
+}
+
+// Err — план нельзя записать: пул задан, а делить его не на что
+func (p DistributionPlan) Err() error {
+	if p.PoolAmount == nil {
+		return nil
+	}
+	for _, line := range p.Lines {
+		if line.CapitalBase.Sign() > 0 {
+			return nil
+		}
+	}
+	return ErrPoolNoCapital
+}
+
+// capitalBefore — капитал инвестора по операциям, датированным раньше date
+func capitalBefore(inv models.Investor, payouts []models.Payout, date time.Time) models.Decimal {
+	capital := inv.InvestedAmount
+	for _, p := range Active(payouts) {
+		if p.InvestorID == inv.ID && p.PeriodDate.Before(date) {
+			capital = capital.Add(capitalDelta(p))
+		}
+	}
+	return capital
+}
+
+// Payouts превращает план в строки payouts; нулевые суммы пропускаются
+func (p DistributionPlan) Payouts() []models.Payout {
+	var out []models.Payout
+
+	for _, line := range p.Lines {
+		if line.Mode == ModeSkip || line.Amount.IsZero() {
+			continue
+		}
+
+		typ := models.PayoutReinvest
+		if line.Mode == ModeWithdraw {
+			typ = models.PayoutProfitWithdrawal
+		}
+
+		out = append(out, models.Payout{
+			InvestorID:   line.InvestorID,
+			PeriodDate:   p.PeriodDate,
+			PayoutAmount: line.Amount,
+			Type:         typ,
+		})
+	}
+
+	return out
+}
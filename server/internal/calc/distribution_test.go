@@ -0,0 +1,218 @@
+package calc
+
+import (
+	"errors"
+	"invest/internal/models"
+	"testing"
+)
+
+func distributionFixture() ([]models.Investor, []models.Payout) {
+	investors := []models.Investor{
+		{ID: 1, FullName: "Иванов", InvestedAmount: models.DecimalFromInt(100000), ProfitShare: models.DecimalFromInt(50)},
+		{ID: 2, FullName: "Петров", InvestedAmount: models.DecimalFromInt(300000), ProfitShare: models.DecimalFromInt(60)},
+	}
+	payouts := []models.Payout{
+		// внутри месяца: в базу на начало месяца не входит
+		{ID: 1, InvestorID: 2, PeriodDate: date("2024-01-15"), PayoutAmount: models.DecimalFromInt(100000), Type: models.PayoutTopup},
+		// после месяца: не входит никуда
+		{ID: 2, InvestorID: 1, PeriodDate: date("2024-02-10"), PayoutAmount: models.DecimalFromInt(50000), Type: models.PayoutTopup},
+	}
+	return investors, payouts
+}
+
+type wantLine struct {
+	base, gross, amount, house, newCapital string
+}
+
+func checkLines(t *testing.T, plan DistributionPlan, want []wantLine) {
+	t.Helper()
+
+	if len(plan.Lines) != len(want) {
+		t.Fatalf("lines = %d, want %d", len(plan.Lines), len(want))
+	}
+	for i, w := range want {
+		l := plan.Lines[i]
+		got := wantLine{
+			base:       l.CapitalBase.String(),
+			gross:      l.GrossAmount.String(),
+			amount:     l.Amount.String(),
+			house:      l.HouseAmount.String(),
+			newCapital: l.NewCapital.String(),
+		}
+		if got != w {
+			t.Errorf("line %d = %+v, want %+v", i, got, w)
+		}
+	}
+}
+
+func TestPlanDistributionPercent(t *testing.T) {
+	investors, payouts := distributionFixture()
+
+	plan := PlanDistribution(investors, payouts, DistributionInput{
+		PeriodDate:   date("2024-01-31"),
+		GrossPercent: models.DecimalFromInt(10),
+		DefaultMode:  ModeReinvest,
+	})
+
+	checkLines(t, plan, []wantLine{
+		{base: "100000.00", gross: "10000.00", amount: "5000.00", house: "5000.00", newCapital: "105000.00"},
+		{base: "300000.00", gross: "30000.00", amount: "18000.00", house: "12000.00", newCapital: "418000.00"},
+	})
+
+	if got := plan.TotalAmount.String(); got != "23000.00" {
+		t.Errorf("TotalAmount = %s, want 23000.00", got)
+	}
+	if got := plan.TotalHouse.String(); got != "17000.00" {
+		t.Errorf("TotalHouse = %s, want 17000.00", got)
+	}
+}
+
+func TestPlanDistributionIgnoresLaterOperations(t *testing.T) {
+	investors, payouts := distributionFixture()
+	in := DistributionInput{
+		PeriodDate:   date("2024-01-31"),
+		GrossPercent: models.DecimalFromInt(10),
+		DefaultMode:  ModeReinvest,
+	}
+
+	before := PlanDistribution(investors, payouts, in)
+
+	later := append(payouts,
+		models.Payout{ID: 3, InvestorID: 2, PeriodDate: date("2024-03-01"), PayoutAmount: models.DecimalFromInt(-200000), Type: models.PayoutCapitalWithdrawal},
+	)
+	after := PlanDistribution(investors, later, in)
+
+	for i := range before.Lines {
+		if before.Lines[i].Amount != after.Lines[i].Amount {
+			t.Errorf("line %d amount changed: %s → %s", i, before.Lines[i].Amount, after.Lines[i].Amount)
+		}
+	}
+}
+
+func TestPlanDistributionModes(t *testing.T) {
+	investors, payouts := distributionFixture()
+
+	plan := PlanDistribution(investors, payouts, DistributionInput{
+		PeriodDate:   date("2024-01-31"),
+		GrossPercent: models.DecimalFromInt(10),
+		DefaultMode:  ModeWithdraw,
+		Choices:      map[int64]DistributionMode{2: ModeSkip},
+	})
+
+	checkLines(t, plan, []wantLine{
+		{base: "100000.00", gross: "10000.00", amount: "5000.00", house: "5000.00", newCapital: "100000.00"},
+		// skip: всё остаётся управляющему
+		{base: "300000.00", gross: "30000.00", amount: "0.00", house: "30000.00", newCapital: "400000.00"},
+	})
+
+	rows := plan.Payouts()
+	if len(rows) != 1 {
+		t.Fatalf("payouts = %d, want 1", len(rows))
+	}
+	if rows[0].InvestorID != 1 || rows[0].Type != models.PayoutProfitWithdrawal {
+		t.Errorf("payout = investor %d %s, want investor 1 profit_withdrawal", rows[0].InvestorID, rows[0].Type)
+	}
+}
+
+func TestPlanDistributionPool(t *testing.T) {
+	investors, payouts := distributionFixture()
+
+	plan := PlanDistribution(investors, payouts, DistributionInput{
+		PeriodDate:  date("2024-01-31"),
+		PoolAmount:  models.DecimalFromInt(10000),
+		DefaultMode: ModeReinvest,
+	})
+
+	checkLines(t, plan, []wantLine{
+		{base: "100000.00", gross: "2500.00", amount: "1250.00", house: "1250.00", newCapital: "101250.00"},
+		{base: "300000.00", gross: "7500.00", amount: "4500.00", house: "3000.00", newCapital: "404500.00"},
+	})
+
+	if got := plan.GrossPercent.String(); got != "2.50" {
+		t.Errorf("GrossPercent = %s, want 2.50", got)
+	}
+	if got := plan.TotalGross.String(); got != "10000.00" {
+		t.Errorf("TotalGross = %s, want 10000.00", got)
+	}
+}
+
+func TestPlanDistributionPoolRemainder(t *testing.T) {
+	investors := []models.Investor{
+		{ID: 1, FullName: "А", InvestedAmount: models.DecimalFromInt(1000), ProfitShare: models.DecimalFromInt(50)},
+		{ID: 2, FullName: "Б", InvestedAmount: models.DecimalFromInt(1000), ProfitShare: models.DecimalFromInt(50)},
+		{ID: 3, FullName: "В", InvestedAmount: models.DecimalFromInt(1000), ProfitShare: models.DecimalFromInt(50)},
+		// без капитала — ничего не получает и помечается
+		{ID: 4, FullName: "Г", ProfitShare: models.DecimalFromInt(50)},
+	}
+
+	plan := PlanDistribution(investors, nil, DistributionInput{
+		PeriodDate:  date("2024-01-31"),
+		PoolAmount:  models.DecimalFromInt(100),
+		DefaultMode: ModeReinvest,
+	})
+
+	checkLines(t, plan, []wantLine{
+		{base: "1000.00", gross: "33.34", amount: "16.67", house: "16.67", newCapital: "1016.67"},
+		{base: "1000.00", gross: "33.33", amount: "16.67", house: "16.66", newCapital: "1016.67"},
+		{base: "1000.00", gross: "33.33", amount: "16.67", house: "16.66", newCapital: "1016.67"},
+		{base: "0.00", gross: "0.00", amount: "0.00", house: "0.00", newCapital: "0.00"},
+	})
+
+	// пул делится до копейки: инвесторам и управляющему — ровно пул
+	if got := plan.TotalAmount.Add(plan.TotalHouse).String(); got != "100.00" {
+		t.Errorf("amount + house = %s, want 100.00", got)
+	}
+	if plan.WarningsCount != 1 || len(plan.Lines[3].Warnings) != 1 || plan.Lines[3].Warnings[0] != WarningZeroCapital {
+		t.Errorf("warnings = %d %v, want one zero_capital", plan.WarningsCount, plan.Lines[3].Warnings)
+	}
+}
+
+func TestPlanDistributionPoolNoCapital(t *testing.T) {
+	investors := []models.Investor{
+		{ID: 1, FullName: "А", ProfitShare: models.DecimalFromInt(50)},
+		{ID: 2, FullName: "Б", InvestedAmount: models.DecimalFromInt(1000), ProfitShare: models.DecimalFromInt(50)},
+	}
+	payouts := []models.Payout{
+		// весь капитал снят до начала месяца
+		{ID: 1, InvestorID: 2, PeriodDate: date("2023-12-20"), PayoutAmount: models.DecimalFromInt(-1000), Type: models.PayoutCapitalWithdrawal},
+	}
+
+	plan := PlanDistribution(investors, payouts, DistributionInput{
+		PeriodDate:  date("2024-01-31"),
+		PoolAmount:  models.DecimalFromInt(100),
+		DefaultMode: ModeReinvest,
+	})
+	if err := plan.Err(); !errors.Is(err, ErrPoolNoCapital) {
+		t.Errorf("Err() = %v, want %v", err, ErrPoolNoCapital)
+	}
+
+	// процентный режим без капитала — не ошибка, просто нулевые суммы
+	plan = PlanDistribution(investors, payouts, DistributionInput{
+		PeriodDate:   date("2024-01-31"),
+		GrossPercent: models.DecimalFromInt(10),
+		DefaultMode:  ModeReinvest,
+	})
+	if err := plan.Err(); err != nil {
+		t.Errorf("Err() = %v, want nil", err)
+	}
+}
+
+func TestPlanDistributionHouseNotNegative(t *testing.T) {
+	investors := []models.Investor{
+		{ID: 1, FullName: "А", InvestedAmount: models.DecimalFromInt(1060), ProfitShare: models.DecimalFromInt(100)},
+	}
+
+	plan := PlanDistribution(investors, nil, DistributionInput{
+		PeriodDate:   date("2024-01-31"),
+		GrossPercent: models.DecimalFromInt(1),
+		DefaultMode:  ModeReinvest,
+	})
+
+	// 10.60 округлилось бы до 11 — больше прибыли на капитал
+	checkLines(t, plan, []wantLine{
+		{base: "1060.00", gross: "10.60", amount: "10.60", house: "0.00", newCapital: "1070.60"},
+	})
+	if plan.TotalHouse.Sign() < 0 {
+		t.Errorf("TotalHouse = %s, want >= 0", plan.TotalHouse)
+	}
+}
@@ -0,0 +1,157 @@
+package calc
+
+import (
+	"invest/internal/models"
+	"time"
+)
+
+// ========================
+//         FEES
+// ========================
+
+const WarningAlreadyCharged = "already_charged"
+
+// FeeLine — расчёт комиссий одного инвестора за месяц
+type FeeLine struct {
+	InvestorID  int64          `json:"investor_id"`
+	FullName    string         `json:"full_name"`
+	CapitalBase models.Decimal `json:"capital_base"`
+
+	ManagementPercent models.Decimal `json:"management_percent"` // годовой
+	ManagementFee     models.Decimal `json:"management_fee"`
+
+	// прибыль за всё время за вычетом management-комиссий
+	ProfitBase         models.Decimal `json:"profit_base"`
+	HighWaterMark      models.Decimal `json:"high_water_mark"`
+	PerformancePercent models.Decimal `json:"performance_percent"`
+	PerformanceFee     models.Decimal `json:"performance_fee"`
+	NewHighWaterMark   models.Decimal `json:"new_high_water_mark"`
+
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+type FeePlan struct {
+	PeriodDate time.Time `json:"period_date"`
+	Lines      []FeeLine `json:"lines"`
+
+	TotalManagement  models.Decimal `json:"total_management"`
+	TotalPerformance models.Decimal `json:"total_performance"`
+	TotalAmount      models.Decimal `json:"total_amount"`
+}
+
+// PlanFees считает комиссии за месяц date:
+// management = капитал × годовой % / 12,
+// performance = % × (прибыль − management-комиссии − high-water mark), если > 0.
+// Капитал и прибыль — на дату date: более поздние операции не учитываются.
+// Вид комиссии, уже списанный в этом месяце, повторно не начисляется.
+func PlanFees(investors []models.Investor, payouts []models.Payout, date time.Time) FeePlan {
+	plan := FeePlan{PeriodDate: date}
+
+	charged := make(map[int64]map[models.FeeKind]bool)
+	for _, p := range Active(payouts) {
+		if p.Kind() != models.PayoutFee || p.FeeKind == nil || !sameMonth(p.PeriodDate, date) {
+			continue
+		}
+		if charged[p.InvestorID] == nil {
+			charged[p.InvestorID] = make(map[models.FeeKind]bool)
+		}
+		charged[p.InvestorID][*p.FeeKind] = true
+	}
+
+	var upTo []models.Payout
+	for _, p := range payouts {
+		if !p.PeriodDate.After(date) {
+			upTo = append(upTo, p)
+		}
+	}
+	summaries := SummarizeAll(investors, upTo)
+
+	for i, inv := range investors {
+		s := summaries[i]
+
+		line := FeeLine{
+			InvestorID:         inv.ID,
+			FullName:           inv.FullName,
+			CapitalBase:        s.CapitalNow,
+			ManagementPercent:  inv.ManagementFeePercent,
+			HighWaterMark:      inv.HighWaterMark,
+			PerformancePercent: inv.PerformanceFeePercent,
+			NewHighWaterMark:   inv.HighWaterMark,
+		}
+
+		managementFees := s.ManagementFees
+
+		if inv.ManagementFeePercent.Sign() > 0 && s.CapitalNow.Sign() > 0 {
+			if charged[inv.ID][models.FeeManagement] {
+				line.Warnings = append(line.Warnings, WarningAlreadyCharged)
+			} else {
+				line.ManagementFee = s.CapitalNow.MulRatio(inv.ManagementFeePercent, models.DecimalFromInt(1200))
+				managementFees = managementFees.Add(line.ManagementFee)
+			}
+		}
+
+		line.ProfitBase = s.TotalProfitAllTime.Sub(managementFees)
+
+		if inv.PerformanceFeePercent.Sign() > 0 && line.ProfitBase.Cmp(inv.HighWaterMark) > 0 {
+			if charged[inv.ID][models.FeePerformance] {
+				if len(line.Warnings) == 0 {
+					line.Warnings = append(line.Warnings, WarningAlreadyCharged)
+				}
+			} else {
+				line.PerformanceFee = line.ProfitBase.Sub(inv.HighWaterMark).MulPercent(inv.PerformanceFeePercent)
+				line.NewHighWaterMark = line.ProfitBase
+			}
+		}
+
+		plan.Lines = append(plan.Lines, line)
+		plan.TotalManagement = plan.TotalManagement.Add(line.ManagementFee)
+		plan.TotalPerformance = plan.TotalPerformance.Add(line.PerformanceFee)
+	}
+
+	plan.TotalAmount = plan.TotalManagement.Add(plan.TotalPerformance)
+
+	return plan
+}
+
+// Payouts превращает план в строки type=fee; нулевые суммы пропускаются
+func (p FeePlan) Payouts() []models.Payout {
+	var out []models.Payout
+
+	add := func(investorID int64, amount models.Decimal, kind models.FeeKind, prevMark *models.Decimal) {
+		if amount.Sign() <= 0 {
+			return
+		}
+		out = append(out, models.Payout{
+			InvestorID:        investorID,
+			PeriodDate:        p.PeriodDate,
+			PayoutAmount:      amount,
+			Type:              models.PayoutFee,
+			FeeKind:           &kind,
+			PrevHighWaterMark: prevMark,
+		})
+	}
+
+	for _, line := range p.Lines {
+		add(line.InvestorID, line.ManagementFee, models.FeeManagement, nil)
+		// прежняя отметка нужна, чтобы вернуть её при отмене комиссии
+		prevMark := line.HighWaterMark
+		add(line.InvestorID, line.PerformanceFee, models.FeePerformance, &prevMark)
+	}
+
+	return out
+}
+
+// HighWaterMarks — новые high-water mark тех, с кого взята performance-комиссия
+func (p FeePlan) HighWaterMarks() map[int64]models.Decimal {
+	out := make(map[int64]models.Decimal)
+	for _, line := range p.Lines {
+		if line.PerformanceFee.Sign() > 0 {
+			out[line.InvestorID] = line.NewHighWaterMark
+		}
+	}
+	return out
+}
+
+func sameMonth(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.Month() == b.Month()
+}
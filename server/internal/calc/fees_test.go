@@ -0,0 +1,50 @@
+package calc
+
+import (
+	"invest/internal/models"
+	"testing"
+)
+
+func TestPlanFeesAsOfDate(t *testing.T) {
+	investors := []models.Investor{{
+		ID:                    1,
+		InvestedAmount:        models.DecimalFromInt(120000),
+		ManagementFeePercent:  models.DecimalFromInt(12),
+		PerformanceFeePercent: models.DecimalFromInt(20),
+		HighWaterMark:         models.DecimalFromInt(1000),
+	}}
+	payouts := []models.Payout{
+		{ID: 1, InvestorID: 1, PeriodDate: date("2024-01-31"), PayoutAmount: models.DecimalFromInt(6000), Type: models.PayoutReinvest},
+		// после даты комиссии: ни в капитал, ни в прибыль не входят
+		{ID: 2, InvestorID: 1, PeriodDate: date("2024-02-10"), PayoutAmount: models.DecimalFromInt(100000), Type: models.PayoutTopup},
+		{ID: 3, InvestorID: 1, PeriodDate: date("2024-02-15"), PayoutAmount: models.DecimalFromInt(9000), Type: models.PayoutReinvest},
+	}
+
+	plan := PlanFees(investors, payouts, date("2024-01-31"))
+	line := plan.Lines[0]
+
+	if got := line.CapitalBase.String(); got != "126000.00" {
+		t.Errorf("CapitalBase = %s, want 126000.00", got)
+	}
+	if got := line.ManagementFee.String(); got != "1260.00" {
+		t.Errorf("ManagementFee = %s, want 1260.00", got)
+	}
+	// прибыль 6000 − management 1260 = 4740; сверх отметки 1000 — 3740 × 20%
+	if got := line.PerformanceFee.String(); got != "748.00" {
+		t.Errorf("PerformanceFee = %s, want 748.00", got)
+	}
+	if got := line.NewHighWaterMark.String(); got != "4740.00" {
+		t.Errorf("NewHighWaterMark = %s, want 4740.00", got)
+	}
+
+	rows := plan.Payouts()
+	if len(rows) != 2 {
+		t.Fatalf("payouts = %d, want 2", len(rows))
+	}
+	if rows[0].PrevHighWaterMark != nil {
+		t.Errorf("management fee prev mark = %s, want nil", rows[0].PrevHighWaterMark)
+	}
+	if rows[1].PrevHighWaterMark == nil || rows[1].PrevHighWaterMark.String() != "1000.00" {
+		t.Errorf("performance fee prev mark = %v, want 1000.00", rows[1].PrevHighWaterMark)
+	}
+}
@@ -0,0 +1,120 @@
+package calc
+
+import (
+	"invest/internal/models"
+	"time"
+)
+
+// ========================
+//       FORECAST
+// ========================
+
+// ForecastInput — допущения прогноза
+type ForecastInput struct {
+	Start        time.Time // первый месяц прогноза
+	Months       int
+	GrossPercent models.Decimal // предполагаемая доходность фонда за месяц
+	ReinvestRate models.Decimal // какая доля прибыли реинвестируется, 0..100
+
+	// плановые движения капитала: каждый месяц и разовые по месяцам
+	MonthlyTopup      models.Decimal
+	MonthlyWithdrawal models.Decimal
+	Flows             map[time.Time]models.Decimal // ключ — первое число месяца; + пополнение, − снятие
+}
+
+// ForecastPoint — один месяц прогноза
+type ForecastPoint struct {
+	Month         time.Time      `json:"month"`
+	StartCapital  models.Decimal `json:"start_capital"`
+	Profit        models.Decimal `json:"profit"`
+	Reinvested    models.Decimal `json:"reinvested"`
+	Withdrawn     models.Decimal `json:"withdrawn"` // выведенная прибыль
+	CapitalFlow   models.Decimal `json:"capital_flow"`
+	ManagementFee models.Decimal `json:"management_fee"`
+	EndCapital    models.Decimal `json:"end_capital"`
+
+	CumulativeProfit    models.Decimal `json:"cumulative_profit"`
+	CumulativeWithdrawn models.Decimal `json:"cumulative_withdrawn"`
+}
+
+type Forecast struct {
+	InvestorID       int64          `json:"investor_id"`
+	StartCapital     models.Decimal `json:"start_capital"`
+	GrossPercent     models.Decimal `json:"gross_percent"`
+	ProfitShare      models.Decimal `json:"profit_share"`
+	EffectivePercent models.Decimal `json:"effective_percent"`
+	ReinvestRate     models.Decimal `json:"reinvest_rate"`
+
+	Points []ForecastPoint `json:"points"`
+
+	EndCapital      models.Decimal `json:"end_capital"`
+	TotalProfit     models.Decimal `json:"total_profit"`
+	TotalWithdrawn  models.Decimal `json:"total_withdrawn"`
+	TotalTopups     models.Decimal `json:"total_topups"`
+	TotalCapitalOut models.Decimal `json:"total_capital_out"`
+	TotalFees       models.Decimal `json:"total_fees"`
+}
+
+// CalcForecast прогнозирует капитал помесячно от текущего капитала.
+// Прибыль месяца считается как в PlanDistribution (капитал × общий % ×
+// profit_share, до рубля), затем делится на реинвест и вывод; плановые
+// движения капитала применяются в конце месяца, снятие — не больше капитала.
+func CalcForecast(inv models.Investor, payouts []models.Payout, in ForecastInput) Forecast {
+	s := Summarize(inv, payouts)
+
+	f := Forecast{
+		InvestorID:       inv.ID,
+		StartCapital:     s.CapitalNow,
+		GrossPercent:     in.GrossPercent,
+		ProfitShare:      inv.ProfitShare,
+		EffectivePercent: in.GrossPercent.MulPercent(inv.ProfitShare),
+		ReinvestRate:     in.ReinvestRate,
+	}
+
+	capital := s.CapitalNow
+	month := time.Date(in.Start.Year(), in.Start.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < in.Months; i++ {
+		pt := ForecastPoint{Month: month, StartCapital: capital}
+
+		if capital.Sign() > 0 {
+			pt.Profit = capital.MulPercent(f.EffectivePercent).RoundUnits()
+		}
+		pt.Reinvested = pt.Profit.MulPercent(in.ReinvestRate)
+		pt.Withdrawn = pt.Profit.Sub(pt.Reinvested)
+		capital = capital.Add(pt.Reinvested)
+
+		if inv.ManagementFeePercent.Sign() > 0 && capital.Sign() > 0 {
+			pt.ManagementFee = capital.MulRatio(inv.ManagementFeePercent, models.DecimalFromInt(1200))
+			capital = capital.Sub(pt.ManagementFee)
+		}
+
+		flow := in.MonthlyTopup.Sub(in.MonthlyWithdrawal).Add(in.Flows[month])
+		if flow.Sign() < 0 && flow.Abs().Cmp(capital) > 0 {
+			flow = models.MaxDecimal(capital, models.Decimal{}).Neg()
+		}
+		pt.CapitalFlow = flow
+		capital = capital.Add(flow)
+
+		pt.EndCapital = capital
+
+		f.TotalProfit = f.TotalProfit.Add(pt.Profit)
+		f.TotalWithdrawn = f.TotalWithdrawn.Add(pt.Withdrawn)
+		f.TotalFees = f.TotalFees.Add(pt.ManagementFee)
+		if flow.Sign() > 0 {
+			f.TotalTopups = f.TotalTopups.Add(flow)
+		} else {
+			f.TotalCapitalOut = f.TotalCapitalOut.Add(flow.Abs())
+		}
+
+		pt.CumulativeProfit = f.TotalProfit
+		pt.CumulativeWithdrawn = f.TotalWithdrawn
+
+		f.Points = append(f.Points, pt)
+		month = month.AddDate(0, 1, 0)
+	}
+
+	f.EndCapital = capital
+
+	return f
+}
@@ -0,0 +1,272 @@
+package calc
+
+import (
+	"errors"
+	"invest/internal/models"
+	"math"
+	"sort"
+	"time"
+)
+
+// ========================
+//      PERFORMANCE
+// ========================
+
+var ErrNoConvergence = errors.New("xirr did not converge")
+
+const daysPerYear = 365.0
+
+// CashFlow — движение денег с точки зрения инвестора:
+// вложения отрицательные, получение денег положительное.
+type CashFlow struct {
+	Date   time.Time `json:"date"`
+	Amount float64   `json:"amount"`
+}
+
+// Performance — доходность инвестора за период
+type Performance struct {
+	InvestorID int64     `json:"investor_id"`
+	From       time.Time `json:"from"`
+	To         time.Time `json:"to"`
+
+	StartCapital  models.Decimal `json:"start_capital"`
+	EndCapital    models.Decimal `json:"end_capital"`
+	Contributions models.Decimal `json:"contributions"` // стартовый капитал + пополнения
+	Distributions models.Decimal `json:"distributions"` // снятия капитала и прибыли
+	Profit        models.Decimal `json:"profit"`
+
+	// доли (0.12 = 12%); nil — посчитать нельзя (нет вложений, не сошлось)
+	ROI           *float64 `json:"roi"`
+	XIRR          *float64 `json:"xirr"`
+	TWR           *float64 `json:"twr"`
+	TWRAnnualized *float64 `json:"twr_annualized"`
+
+	CashFlows []CashFlow `json:"cash_flows"`
+}
+
+// CalcPerformance считает ROI, денежно-взвешенную (XIRR) и
+// время-взвешенную (TWR) доходность по истории операций.
+//
+// Капитал на начало периода — вложение, на конец — получение.
+// Реинвест — это доход внутри фонда, а не движение денег инвестора.
+// Для TWR каждая начисленная прибыль (реинвест или вывод) — доходность
+// подпериода: прибыль / капитал перед начислением.
+func CalcPerformance(inv models.Investor, payouts []models.Payout, from, to time.Time) Performance {
+	payouts = Active(payouts)
+
+	own := make([]models.Payout, 0, len(payouts))
+	for _, p := range payouts {
+		if p.InvestorID == inv.ID {
+			own = append(own, p)
+		}
+	}
+	sort.SliceStable(own, func(i, j int) bool {
+		return own[i].PeriodDate.Before(own[j].PeriodDate)
+	})
+
+	if from.IsZero() || from.Before(inv.CreatedAt) {
+		from = truncateDay(inv.CreatedAt)
+	}
+
+	perf := Performance{InvestorID: inv.ID, From: from, To: to}
+
+	// капитал на начало периода — всё, что было до from
+	capital := inv.InvestedAmount
+	i := 0
+	for ; i < len(own) && own[i].PeriodDate.Before(from); i++ {
+		capital = capital.Add(capitalDelta(own[i]))
+	}
+	perf.StartCapital = capital
+	perf.Contributions = capital
+
+	if capital.Sign() > 0 {
+		perf.CashFlows = append(perf.CashFlows, CashFlow{Date: from, Amount: -capital.Float64()})
+	}
+
+	twr := 1.0
+	twrOK := false
+
+	for ; i < len(own) && !own[i].PeriodDate.After(to); i++ {
+		p := own[i]
+		amount := p.PayoutAmount.Abs()
+
+		switch p.Kind() {
+		case models.PayoutTopup:
+			perf.Contributions = perf.Contributions.Add(amount)
+			perf.CashFlows = append(perf.CashFlows, CashFlow{Date: p.PeriodDate, Amount: -amount.Float64()})
+
+		case models.PayoutCapitalWithdrawal:
+			perf.Distributions = perf.Distributions.Add(amount)
+			perf.CashFlows = append(perf.CashFlows, CashFlow{Date: p.PeriodDate, Amount: amount.Float64()})
+
+		case models.PayoutProfitWithdrawal:
+			perf.Distributions = perf.Distributions.Add(amount)
+			perf.CashFlows = append(perf.CashFlows, CashFlow{Date: p.PeriodDate, Amount: amount.Float64()})
+			if capital.Sign() > 0 {
+				twr *= 1 + amount.Float64()/capital.Float64()
+				twrOK = true
+			}
+
+		case models.PayoutReinvest:
+			if capital.Sign() > 0 {
+				twr *= 1 + p.PayoutAmount.Float64()/capital.Float64()
+				twrOK = true
+			}
+
+		case models.PayoutFee:
+			// доходность считается за вычетом комиссий
+			if capital.Sign() > 0 {
+				twr *= 1 - amount.Float64()/capital.Float64()
+				twrOK = true
+			}
+
+		case models.PayoutTax:
+			// и за вычетом налога: с вывода — инвестор получил меньше,
+			// с реинвеста — налог уменьшил капитал
+			if p.TaxOnWithdrawal {
+				perf.Distributions = perf.Distributions.Sub(amount)
+				perf.CashFlows = append(perf.CashFlows, CashFlow{Date: p.PeriodDate, Amount: -amount.Float64()})
+			}
+			if capital.Sign() > 0 {
+				twr *= 1 - amount.Float64()/capital.Float64()
+				twrOK = true
+			}
+		}
+
+		capital = capital.Add(capitalDelta(p))
+	}
+
+	perf.EndCapital = capital
+	if capital.Sign() > 0 {
+		perf.CashFlows = append(perf.CashFlows, CashFlow{Date: to, Amount: capital.Float64()})
+	}
+
+	// прибыль = всё полученное + остаток − всё вложенное
+	perf.Profit = perf.Distributions.Add(perf.EndCapital).Sub(perf.Contributions)
+
+	if perf.Contributions.Sign() > 0 {
+		roi := perf.Profit.Float64() / perf.Contributions.Float64()
+		perf.ROI = &roi
+	}
+
+	if rate, err := XIRR(perf.CashFlows); err == nil {
+		perf.XIRR = &rate
+	}
+
+	if twrOK {
+		r := twr - 1
+		perf.TWR = &r
+
+		years := to.Sub(from).Hours() / 24 / daysPerYear
+		if years > 0 {
+			ann := math.Pow(twr, 1/years) - 1
+			perf.TWRAnnualized = &ann
+		}
+	}
+
+	return perf
+}
+
+// capitalDelta — как операция меняет капитал (те же правила, что в Summarize)
+func capitalDelta(p models.Payout) models.Decimal {
+	switch p.Kind() {
+	case models.PayoutReinvest, models.PayoutTopup, models.PayoutAdjustment:
+		return p.PayoutAmount
+	case models.PayoutCapitalWithdrawal, models.PayoutFee:
+		return p.PayoutAmount.Abs().Neg()
+	case models.PayoutTax:
+		if !p.TaxOnWithdrawal {
+			return p.PayoutAmount.Abs().Neg()
+		}
+	}
+	return models.Decimal{}
+}
+
+// XIRR — годовая ставка, при которой NPV потоков равна нулю (Actual/365).
+// Нужен хотя бы один отрицательный и один положительный поток.
+func XIRR(flows []CashFlow) (float64, error) {
+	if len(flows) < 2 {
+		return 0, ErrNoConvergence
+	}
+
+	hasNeg, hasPos := false, false
+	for _, f := range flows {
+		if f.Amount < 0 {
+			hasNeg = true
+		}
+		if f.Amount > 0 {
+			hasPos = true
+		}
+	}
+	if !hasNeg || !hasPos {
+		return 0, ErrNoConvergence
+	}
+
+	// допуск по NPV — относительно оборота: для потоков в миллионы рублей
+	// абсолютные 1e-7 недостижимы в float64, для копеек — слишком грубы
+	t0 := flows[0].Date
+	var turnover float64
+	for _, f := range flows {
+		if f.Date.Before(t0) {
+			t0 = f.Date
+		}
+		turnover += math.Abs(f.Amount)
+	}
+	tol := 1e-10 * turnover
+
+	npv := func(rate float64) (float64, float64) {
+		var v, dv float64
+		for _, f := range flows {
+			t := f.Date.Sub(t0).Hours() / 24 / daysPerYear
+			d := math.Pow(1+rate, t)
+			v += f.Amount / d
+			dv -= t * f.Amount / (d * (1 + rate))
+		}
+		return v, dv
+	}
+
+	// Ньютон от 10%
+	rate := 0.1
+	for iter := 0; iter < 100; iter++ {
+		v, dv := npv(rate)
+		if math.Abs(v) < tol {
+			return rate, nil
+		}
+		if dv == 0 {
+			break
+		}
+		next := rate - v/dv
+		if next <= -1 || math.IsNaN(next) || math.IsInf(next, 0) {
+			break
+		}
+		if math.Abs(next-rate) < 1e-10 {
+			return next, nil
+		}
+		rate = next
+	}
+
+	// запасной вариант — бисекция на (-0.9999, 100)
+	lo, hi := -0.9999, 100.0
+	vlo, _ := npv(lo)
+	vhi, _ := npv(hi)
+	if vlo*vhi > 0 {
+		return 0, ErrNoConvergence
+	}
+	for iter := 0; iter < 300; iter++ {
+		mid := (lo + hi) / 2
+		vmid, _ := npv(mid)
+		if math.Abs(vmid) < tol || hi-lo < 1e-12 {
+			return mid, nil
+		}
+		if vlo*vmid < 0 {
+			hi = mid
+		} else {
+			lo, vlo = mid, vmid
+		}
+	}
+	return 0, ErrNoConvergence
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
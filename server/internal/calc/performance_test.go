@@ -0,0 +1,150 @@
+package calc
+
+import (
+	"errors"
+	"invest/internal/models"
+	"math"
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	d, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func TestXIRR(t *testing.T) {
+	tests := []struct {
+		name  string
+		flows []CashFlow
+		want  float64
+	}{
+		{
+			name: "год, +10%",
+			flows: []CashFlow{
+				{Date: date("2023-01-01"), Amount: -1000},
+				{Date: date("2024-01-01"), Amount: 1100},
+			},
+			want: 0.1,
+		},
+		{
+			name: "два года, 10% годовых",
+			flows: []CashFlow{
+				{Date: date("2022-01-01"), Amount: -1000},
+				{Date: date("2024-01-01"), Amount: 1210},
+			},
+			want: 0.1,
+		},
+		{
+			name: "пример из документации Excel",
+			flows: []CashFlow{
+				{Date: date("2008-01-01"), Amount: -10000},
+				{Date: date("2008-03-01"), Amount: 2750},
+				{Date: date("2008-10-30"), Amount: 4250},
+				{Date: date("2009-02-15"), Amount: 3250},
+				{Date: date("2009-04-01"), Amount: 2750},
+			},
+			want: 0.373362535,
+		},
+		{
+			name: "суммы в сотни миллионов рублей",
+			flows: []CashFlow{
+				{Date: date("2021-01-01"), Amount: -100_000_000},
+				{Date: date("2022-01-01"), Amount: -50_000_000},
+				{Date: date("2023-01-01"), Amount: 176_000_000},
+			},
+			want: 0.1,
+		},
+		{
+			name: "убыток",
+			flows: []CashFlow{
+				{Date: date("2023-01-01"), Amount: -1000},
+				{Date: date("2024-01-01"), Amount: 800},
+			},
+			want: -0.2,
+		},
+		{
+			name: "потоки не по порядку дат",
+			flows: []CashFlow{
+				{Date: date("2024-01-01"), Amount: 1100},
+				{Date: date("2023-01-01"), Amount: -1000},
+			},
+			want: 0.1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := XIRR(tt.flows)
+			if err != nil {
+				t.Fatalf("XIRR: %v", err)
+			}
+			if math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("XIRR = %.9f, want %.9f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestXIRRNoSolution(t *testing.T) {
+	tests := []struct {
+		name  string
+		flows []CashFlow
+	}{
+		{name: "нет потоков"},
+		{
+			name:  "один поток",
+			flows: []CashFlow{{Date: date("2023-01-01"), Amount: -1000}},
+		},
+		{
+			name: "только вложения",
+			flows: []CashFlow{
+				{Date: date("2023-01-01"), Amount: -1000},
+				{Date: date("2023-06-01"), Amount: -500},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := XIRR(tt.flows); !errors.Is(err, ErrNoConvergence) {
+				t.Errorf("err = %v, want ErrNoConvergence", err)
+			}
+		})
+	}
+}
+
+func TestCalcPerformance(t *testing.T) {
+	inv := models.Investor{
+		ID:             1,
+		InvestedAmount: models.DecimalFromInt(100000),
+		CreatedAt:      date("2023-01-01"),
+	}
+	payouts := []models.Payout{
+		{InvestorID: 1, PeriodDate: date("2023-06-30"), PayoutAmount: models.DecimalFromInt(5000), Type: models.PayoutReinvest},
+		{InvestorID: 1, PeriodDate: date("2023-12-31"), PayoutAmount: models.DecimalFromInt(5250), Type: models.PayoutProfitWithdrawal},
+		{InvestorID: 2, PeriodDate: date("2023-12-31"), PayoutAmount: models.DecimalFromInt(999), Type: models.PayoutReinvest},
+	}
+
+	perf := CalcPerformance(inv, payouts, time.Time{}, date("2023-12-31"))
+
+	if got, want := perf.EndCapital.String(), "105000.00"; got != want {
+		t.Errorf("EndCapital = %s, want %s", got, want)
+	}
+	if got, want := perf.Profit.String(), "10250.00"; got != want {
+		t.Errorf("Profit = %s, want %s", got, want)
+	}
+	if perf.ROI == nil || math.Abs(*perf.ROI-0.1025) > 1e-9 {
+		t.Errorf("ROI = %v, want 0.1025", perf.ROI)
+	}
+	// два подпериода по +5%
+	if perf.TWR == nil || math.Abs(*perf.TWR-0.1025) > 1e-9 {
+		t.Errorf("TWR = %v, want 0.1025", perf.TWR)
+	}
+	if perf.XIRR == nil {
+		t.Fatal("XIRR = nil")
+	}
+}
@@ -0,0 +1,79 @@
+package calc
+
+import (
+	"errors"
+	"invest/internal/models"
+	"time"
+)
+
+// ========================
+//       SCHEDULES
+// ========================
+
+var (
+	ErrNothingScheduled  = errors.New("nothing to post: scheduled amount is zero")
+	ErrInvestorNotActive = errors.New("investor is not active")
+)
+
+// ScheduleDueDates — даты расписания в [from, to] включительно.
+// День 29–31 в коротком месяце переносится на последний день месяца.
+func ScheduleDueDates(sc models.Schedule, from, to time.Time) []time.Time {
+	from = truncateDay(from)
+	to = truncateDay(to)
+
+	if sc.StartDate.After(from) {
+		from = truncateDay(sc.StartDate)
+	}
+	if sc.EndDate != nil && sc.EndDate.Before(to) {
+		to = truncateDay(*sc.EndDate)
+	}
+
+	var out []time.Time
+	month := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for !month.After(to) {
+		day := sc.DayOfMonth
+		if last := month.AddDate(0, 1, -1).Day(); day > last {
+			day = last
+		}
+		due := month.AddDate(0, 0, day-1)
+		if !due.Before(from) && !due.After(to) {
+			out = append(out, due)
+		}
+		month = month.AddDate(0, 1, 0)
+	}
+	return out
+}
+
+// ScheduledPayout собирает выплату по расписанию на дату due.
+// Для net_profit сумма — накопленная чистая прибыль на момент проведения;
+// снятия проверяются так же, как ручные (CheckWithdrawal).
+func ScheduledPayout(sc models.Schedule, inv models.Investor, payouts []models.Payout, due time.Time) (models.Payout, error) {
+	p := models.Payout{
+		InvestorID: sc.InvestorID,
+		PeriodDate: due,
+		Type:       sc.Type,
+	}
+
+	// у приостановленных и закрытых регулярные операции пропускаются
+	if inv.Status != models.InvestorActive {
+		return p, ErrInvestorNotActive
+	}
+
+	switch sc.Rule {
+	case models.ScheduleNetProfit:
+		p.PayoutAmount = Summarize(inv, payouts).NetProfitNow
+	default:
+		if sc.Amount != nil {
+			p.PayoutAmount = *sc.Amount
+		}
+	}
+
+	if p.PayoutAmount.Sign() <= 0 {
+		return p, ErrNothingScheduled
+	}
+	if p.Type == models.PayoutCapitalWithdrawal {
+		p.PayoutAmount = p.PayoutAmount.Neg()
+	}
+
+	return p, CheckWithdrawal(inv, payouts, p)
+}
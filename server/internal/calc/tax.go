@@ -0,0 +1,59 @@
+package calc
+
+import (
+	"invest/internal/models"
+)
+
+// ========================
+//          TAX
+// ========================
+
+// TaxYear — удержанный налог инвестора за календарный год
+type TaxYear struct {
+	InvestorID  int64          `json:"investor_id"`
+	FullName    string         `json:"full_name"`
+	TaxResident bool           `json:"tax_resident"`
+	TaxRate     models.Decimal `json:"tax_rate"`
+	Year        int            `json:"year"`
+
+	GrossProfit models.Decimal `json:"gross_profit"` // реинвесты и выводы прибыли
+	TaxWithheld models.Decimal `json:"tax_withheld"`
+	NetProfit   models.Decimal `json:"net_profit"`
+}
+
+// AnnualTax считает по каждому инвестору прибыль и удержанный налог
+// за год по period_date; отменённые пары не учитываются
+func AnnualTax(investors []models.Investor, payouts []models.Payout, year int) []TaxYear {
+	byInvestor := make(map[int64]*TaxYear, len(investors))
+	out := make([]TaxYear, len(investors))
+	for i, inv := range investors {
+		out[i] = TaxYear{
+			InvestorID:  inv.ID,
+			FullName:    inv.FullName,
+			TaxResident: inv.TaxResident,
+			TaxRate:     inv.TaxRate,
+			Year:        year,
+		}
+		byInvestor[inv.ID] = &out[i]
+	}
+
+	for _, p := range Active(payouts) {
+		t := byInvestor[p.InvestorID]
+		if t == nil || p.PeriodDate.Year() != year {
+			continue
+		}
+
+		switch p.Kind() {
+		case models.PayoutReinvest, models.PayoutProfitWithdrawal:
+			t.GrossProfit = t.GrossProfit.Add(p.PayoutAmount.Abs())
+		case models.PayoutTax:
+			t.TaxWithheld = t.TaxWithheld.Add(p.PayoutAmount.Abs())
+		}
+	}
+
+	for i := range out {
+		out[i].NetProfit = out[i].GrossProfit.Sub(out[i].TaxWithheld)
+	}
+
+	return out
+}
@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"strings"
+	"time"
 )
 
 
@@ -19,6 +20,15 @@ type Config struct {
 
 	JWTSecret      string
 	SecretRegCode  string
+
+	// "capital" — учёт капитала суммами (по умолчанию), "units" — паевой учёт (NAV)
+	FundMode string
+
+	// как часто планировщик проводит регулярные операции; 0 — выключен
+	SchedulerInterval time.Duration
+
+	// соглашение о днях для pro-rata базы распределения: "act/act" или "30/360"
+	DayCount string
 }
 
 
@@ -35,6 +45,11 @@ func Load() *Config {
 		JWTSecret:     getEnv("JWT_SECRET", "change_me_jwt_secret"),
 		SecretRegCode: getEnv("SECRET_REG_CODE", "change_me_reg_code"),
 
+		FundMode: getEnv("FUND_MODE", "capital"),
+
+		SchedulerInterval: getDuration("SCHEDULER_INTERVAL", time.Hour),
+		DayCount:          getEnv("DAY_COUNT", "act/act"),
+
 	}
 
 	// CORS может содержать несколько доменов через запятую
@@ -66,6 +81,23 @@ func getEnv(key, def string) string {
 	return val
 }
 
+func getDuration(key string, def time.Duration) time.Duration {
+	raw := getEnv(key, "")
+	if raw == "" {
+		return def
+	}
+	if raw == "0" || raw == "off" {
+		return 0
+	}
+
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < 0 {
+		log.Printf("invalid %s=%q, using %s", key, raw, def)
+		return def
+	}
+	return d
+}
+
 func parseCORS(raw string) []string {
 	raw = strings.TrimSpace(raw)
 	if raw == "" {
@@ -0,0 +1,211 @@
+package http
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"invest/internal/models"
+	"invest/internal/repository"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//
+// ========================
+//       AGREEMENTS
+// ========================
+//
+
+type agreementRequest struct {
+	InvestorID     int64          `json:"investorId"` // только при создании
+	FundID         int64          `json:"fundId"`     // только при создании; 0 — фонд по умолчанию
+	Number         string         `json:"number"`
+	StartDate      string         `json:"startDate"`
+	EndDate        string         `json:"endDate"`     // пусто — бессрочный
+	LockupUntil    string         `json:"lockupUntil"` // пусто — без lock-up
+	NoticeDays     int            `json:"noticeDays"`
+	PenaltyPercent models.Decimal `json:"penaltyPercent"`
+}
+
+func (req agreementRequest) toAgreement() (models.Agreement, string) {
+	a := models.Agreement{
+		InvestorID:     req.InvestorID,
+		FundID:         req.FundID,
+		Number:         strings.TrimSpace(req.Number),
+		NoticeDays:     req.NoticeDays,
+		PenaltyPercent: req.PenaltyPercent,
+	}
+
+	if a.Number == "" {
+		return a, "number required"
+	}
+
+	start, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return a, "invalid startDate, must be YYYY-MM-DD"
+	}
+	a.StartDate = start
+
+	if req.EndDate != "" {
+		end, err := time.Parse("2006-01-02", req.EndDate)
+		if err != nil {
+			return a, "invalid endDate, must be YYYY-MM-DD"
+		}
+		if end.Before(start) {
+			return a, "endDate must not be before startDate"
+		}
+		a.EndDate = &end
+	}
+
+	if req.LockupUntil != "" {
+		lockup, err := time.Parse("2006-01-02", req.LockupUntil)
+		if err != nil {
+			return a, "invalid lockupUntil, must be YYYY-MM-DD"
+		}
+		if lockup.Before(start) {
+			return a, "lockupUntil must not be before startDate"
+		}
+		a.LockupUntil = &lockup
+	}
+
+	if a.NoticeDays < 0 {
+		return a, "noticeDays must be >= 0"
+	}
+	if a.PenaltyPercent.Sign() < 0 || a.PenaltyPercent.Cmp(maxProfitShare) > 0 {
+		return a, "penaltyPercent must be between 0 and 100"
+	}
+
+	return a, ""
+}
+
+// GET /api/agreements?investorId=&fundId= / POST /api/agreements
+func (s *Server) handleAgreements(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	switch r.Method {
+
+	case http.MethodGet:
+		repo, ok := s.fundRepo(w, r)
+		if !ok {
+			return
+		}
+
+		var investorID int64
+		if v := r.URL.Query().Get("investorId"); v != "" {
+			id, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				writeJSON(w, 400, errorResponse{Error: "invalid investorId"})
+				return
+			}
+			investorID = id
+		}
+
+		list, err := repo.ListAgreements(ctx, investorID)
+		if err != nil {
+			writeJSON(w, 500, errorResponse{Error: err.Error()})
+			return
+		}
+		writeJSON(w, 200, list)
+
+	case http.MethodPost:
+		var req agreementRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, 400, errorResponse{Error: "invalid json"})
+			return
+		}
+
+		a, msg := req.toAgreement()
+		if msg != "" {
+			writeJSON(w, 400, errorResponse{Error: msg})
+			return
+		}
+
+		fundID, ok := s.resolveFund(ctx, w, a.FundID)
+		if !ok {
+			return
+		}
+		a.FundID = fundID
+
+		a.CreatedBy = nullableUserID(ctx)
+		err := s.repo.CreateAgreement(ctx, &a)
+		if !writeAgreementError(w, err) {
+			return
+		}
+		writeJSON(w, 201, a)
+
+	default:
+		w.WriteHeader(405)
+	}
+}
+
+// GET / PUT / DELETE /api/agreements/{id}
+func (s *Server) handleAgreementByID(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/agreements/")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		writeJSON(w, 400, errorResponse{Error: "invalid agreement id"})
+		return
+	}
+
+	switch r.Method {
+
+	case http.MethodGet:
+		a, err := s.repo.GetAgreement(ctx, id)
+		if !writeAgreementError(w, err) {
+			return
+		}
+		writeJSON(w, 200, a)
+
+	// новые условия действуют для следующих снятий; начисленные штрафы не меняются
+	case http.MethodPut:
+		var req agreementRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, 400, errorResponse{Error: "invalid json"})
+			return
+		}
+
+		a, msg := req.toAgreement()
+		if msg != "" {
+			writeJSON(w, 400, errorResponse{Error: msg})
+			return
+		}
+		a.ID = id
+
+		err := s.repo.UpdateAgreement(ctx, &a)
+		if !writeAgreementError(w, err) {
+			return
+		}
+		writeJSON(w, 200, a)
+
+	case http.MethodDelete:
+		err := s.repo.DeleteAgreement(ctx, id)
+		if !writeAgreementError(w, err) {
+			return
+		}
+		writeJSON(w, 200, map[string]string{"message": "deleted"})
+
+	default:
+		w.WriteHeader(405)
+	}
+}
+
+// writeAgreementError пишет ответ с ошибкой; false — если ошибка была
+func writeAgreementError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, sql.ErrNoRows):
+		writeJSON(w, 404, errorResponse{Error: "agreement or investor not found"})
+	case errors.Is(err, repository.ErrAgreementExists):
+		writeJSON(w, 409, errorResponse{Error: err.Error()})
+	case errors.Is(err, repository.ErrNoPosition):
+		writeJSON(w, 422, errorResponse{Error: err.Error()})
+	default:
+		writeJSON(w, 500, errorResponse{Error: err.Error()})
+	}
+	return false
+}
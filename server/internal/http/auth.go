@@ -172,3 +172,34 @@ func (s *Server) withAuth(next http.HandlerFunc) http.HandlerFunc {
 		next(w, r.WithContext(ctx))
 	}
 }
+
+// withAdmin — только для привилегированных пользователей (users.is_admin)
+func (s *Server) withAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return s.withAuth(func(w http.ResponseWriter, r *http.Request) {
+		u, err := s.repo.GetUserByID(r.Context(), userIDFromContext(r.Context()))
+		if err != nil {
+			writeJSON(w, 500, errorResponse{Error: err.Error()})
+			return
+		}
+		if u == nil || !u.IsAdmin {
+			writeJSON(w, 403, errorResponse{Error: "admin only"})
+			return
+		}
+		next(w, r)
+	})
+}
+
+// userIDFromContext — id пользователя, проставленный withAuth (0, если нет)
+func userIDFromContext(ctx context.Context) int64 {
+	id, _ := ctx.Value(userIDCtxKey).(int64)
+	return id
+}
+
+// nullableUserID — для колонок created_by / changed_by (NULL, если пользователь неизвестен)
+func nullableUserID(ctx context.Context) *int64 {
+	id := userIDFromContext(ctx)
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
@@ -0,0 +1,66 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultDashboardMonths = 12
+	maxDashboardMonths     = 120
+	defaultDashboardTop    = 10
+	maxDashboardTop        = 100
+)
+
+//
+// ========================
+//       DASHBOARD
+// ========================
+//
+
+// GET /api/dashboard?months=12&top=10&fundId= — сводка по фонду одним запросом
+// (без fundId — по всем фондам):
+// капитал под управлением, прибыль за месяц и за всё время,
+// чистый приток по месяцам, число активных инвесторов, крупнейшие позиции
+func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(405)
+		return
+	}
+
+	repo, ok := s.fundRepo(w, r)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+
+	months := defaultDashboardMonths
+	if v := q.Get("months"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxDashboardMonths {
+			writeJSON(w, 400, errorResponse{Error: "months must be between 1 and 120"})
+			return
+		}
+		months = n
+	}
+
+	top := defaultDashboardTop
+	if v := q.Get("top"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxDashboardTop {
+			writeJSON(w, 400, errorResponse{Error: "top must be between 1 and 100"})
+			return
+		}
+		top = n
+	}
+
+	d, err := repo.GetDashboard(r.Context(), time.Now().UTC(), months, top)
+	if err != nil {
+		writeJSON(w, 500, errorResponse{Error: err.Error()})
+		return
+	}
+
+	writeJSON(w, 200, d)
+}
@@ -0,0 +1,277 @@
+package http
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"invest/internal/calc"
+	"invest/internal/models"
+	"invest/internal/repository"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var errNothingToDistribute = errors.New("nothing to distribute: all amounts are zero")
+
+//
+// ========================
+//      DISTRIBUTIONS
+// ========================
+//
+
+type distributionRequest struct {
+	Date         string         `json:"date"`
+	GrossPercent models.Decimal `json:"grossPercent"`
+	PoolAmount   models.Decimal `json:"poolAmount"` // вместо grossPercent: прибыль фонда суммой
+	DefaultMode  string         `json:"defaultMode"`
+	DryRun       bool           `json:"dryRun"`
+	FundID       int64          `json:"fundId"` // 0 — фонд по умолчанию
+
+	// база — средний по дням капитал за месяц; dayCount по умолчанию из DAY_COUNT
+	ProRata   bool   `json:"proRata"`
+	DayCount  string `json:"dayCount"`
+	Investors []struct {
+		InvestorID int64  `json:"investorId"`
+		Mode       string `json:"mode"`
+	} `json:"investors"`
+}
+
+// toInput проверяет запрос и собирает параметры расчёта
+func (req distributionRequest) toInput(dayCount calc.DayCount) (calc.DistributionInput, string) {
+	in := calc.DistributionInput{
+		GrossPercent: req.GrossPercent,
+		PoolAmount:   req.PoolAmount,
+		DefaultMode:  calc.ModeReinvest,
+		Choices:      map[int64]calc.DistributionMode{},
+		ProRata:      req.ProRata,
+		DayCount:     dayCount,
+	}
+
+	if req.DayCount != "" {
+		in.DayCount = calc.DayCount(req.DayCount)
+		if !in.DayCount.Valid() {
+			return in, "dayCount must be act/act or 30/360"
+		}
+	}
+
+	period, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		return in, "invalid date, must be YYYY-MM-DD"
+	}
+	in.PeriodDate = period
+
+	switch {
+	case req.PoolAmount.Sign() < 0:
+		return in, "poolAmount must be positive"
+	case req.PoolAmount.Sign() > 0:
+		if !req.GrossPercent.IsZero() {
+			return in, "use either grossPercent or poolAmount"
+		}
+	case req.GrossPercent.Sign() <= 0 || req.GrossPercent.Cmp(maxProfitShare) > 0:
+		return in, "grossPercent must be between 0 and 100"
+	}
+
+	if req.DefaultMode != "" {
+		in.DefaultMode = calc.DistributionMode(req.DefaultMode)
+		if !in.DefaultMode.Valid() {
+			return in, "defaultMode must be reinvest, withdraw or skip"
+		}
+	}
+
+	for _, c := range req.Investors {
+		mode := calc.DistributionMode(c.Mode)
+		if !mode.Valid() {
+			return in, "mode must be reinvest, withdraw or skip"
+		}
+		in.Choices[c.InvestorID] = mode
+	}
+
+	return in, ""
+}
+
+func (s *Server) handleDistributions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	switch r.Method {
+
+	// ?fundId= — запуски одного фонда
+	case http.MethodGet:
+		repo, ok := s.fundRepo(w, r)
+		if !ok {
+			return
+		}
+
+		list, err := repo.ListDistributions(ctx)
+		if err != nil {
+			writeJSON(w, 500, errorResponse{Error: err.Error()})
+			return
+		}
+		writeJSON(w, 200, list)
+
+	case http.MethodPost:
+		if s.repo.UnitMode() {
+			writeJSON(w, 409, errorResponse{Error: repository.ErrDistributionUnitMode.Error()})
+			return
+		}
+
+		var req distributionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, 400, errorResponse{Error: "invalid json"})
+			return
+		}
+
+		in, msg := req.toInput(s.dayCount)
+		if msg != "" {
+			writeJSON(w, 400, errorResponse{Error: msg})
+			return
+		}
+
+		fundID, ok := s.resolveFund(ctx, w, req.FundID)
+		if !ok {
+			return
+		}
+
+		// предпросмотр: тот же расчёт, но ничего не записываем
+		if req.DryRun {
+			s.previewDistribution(w, r, fundID, in)
+			return
+		}
+
+		d := models.Distribution{
+			FundID:       fundID,
+			PeriodDate:   in.PeriodDate,
+			GrossPercent: in.GrossPercent,
+			CreatedBy:    nullableUserID(ctx),
+		}
+		if in.ProRata {
+			dayCount := string(in.DayCount)
+			d.DayCount = &dayCount
+		}
+		createdBy := d.CreatedBy
+
+		// расчёт выполняется внутри транзакции репозитория
+		var plan calc.DistributionPlan
+		err := s.repo.CreateDistribution(ctx, &d,
+			func(investors []models.Investor, payouts []models.Payout) ([]models.Payout, error) {
+				plan = calc.PlanDistribution(investors, payouts, in)
+				if err := plan.Err(); err != nil {
+					return nil, err
+				}
+				d.GrossPercent = plan.GrossPercent
+				d.PoolAmount = plan.PoolAmount
+				d.HouseAmount = plan.TotalHouse
+				rows := plan.Payouts()
+				if len(rows) == 0 {
+					return nil, errNothingToDistribute
+				}
+				for i := range rows {
+					rows[i].CreatedBy = createdBy
+				}
+				return rows, nil
+			})
+		if errors.Is(err, errNothingToDistribute) || errors.Is(err, calc.ErrPoolNoCapital) {
+			writeJSON(w, 422, errorResponse{Error: err.Error()})
+			return
+		}
+		if errors.Is(err, repository.ErrPeriodClosed) ||
+			errors.Is(err, repository.ErrDistributionExists) ||
+			errors.Is(err, repository.ErrDistributionUnitMode) {
+			writeJSON(w, 409, errorResponse{Error: err.Error()})
+			return
+		}
+		if err != nil {
+			writeJSON(w, 500, errorResponse{Error: err.Error()})
+			return
+		}
+
+		writeJSON(w, 201, map[string]any{
+			"distribution": d,
+			"lines":        plan.Lines,
+		})
+
+	default:
+		w.WriteHeader(405)
+	}
+}
+
+// previewDistribution — dry-run: таблица по инвесторам и итоги по фонду
+func (s *Server) previewDistribution(w http.ResponseWriter, r *http.Request, fundID int64, in calc.DistributionInput) {
+	ctx := r.Context()
+	repo := s.repo.InFund(fundID)
+
+	// сумма вложений и доля — действовавшие в распределяемом месяце, как при записи
+	investors, err := repo.FindInvestors(ctx, repository.InvestorFilter{
+		Statuses: []models.InvestorStatus{models.InvestorActive},
+		TermsAt:  &in.PeriodDate,
+	})
+	if err != nil {
+		writeJSON(w, 500, errorResponse{Error: err.Error()})
+		return
+	}
+
+	payouts, err := repo.GetPayouts(ctx)
+	if err != nil {
+		writeJSON(w, 500, errorResponse{Error: err.Error()})
+		return
+	}
+
+	plan := calc.PlanDistribution(investors, payouts, in)
+	if err := plan.Err(); err != nil {
+		writeJSON(w, 422, errorResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, 200, plan)
+}
+
+// /api/distributions/{id} и /api/distributions/{id}/rollback
+func (s *Server) handleDistributionByID(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	rest := strings.TrimPrefix(r.URL.Path, "/api/distributions/")
+	idStr, sub, _ := strings.Cut(rest, "/")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		writeJSON(w, 400, errorResponse{Error: "invalid distribution id"})
+		return
+	}
+
+	switch {
+
+	case sub == "" && r.Method == http.MethodGet:
+		d, err := s.repo.GetDistribution(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			writeJSON(w, 404, errorResponse{Error: "distribution not found"})
+			return
+		}
+		if err != nil {
+			writeJSON(w, 500, errorResponse{Error: err.Error()})
+			return
+		}
+		writeJSON(w, 200, d)
+
+	case sub == "rollback" && r.Method == http.MethodPost:
+		err := s.repo.RollbackDistribution(ctx, id, userIDFromContext(ctx))
+		if errors.Is(err, sql.ErrNoRows) {
+			writeJSON(w, 404, errorResponse{Error: "distribution not found"})
+			return
+		}
+		if errors.Is(err, repository.ErrDistributionRolledBack) ||
+			errors.Is(err, repository.ErrPeriodClosed) {
+			writeJSON(w, 409, errorResponse{Error: err.Error()})
+			return
+		}
+		if err != nil {
+			writeJSON(w, 500, errorResponse{Error: err.Error()})
+			return
+		}
+		writeJSON(w, 200, map[string]string{"message": "rolled back"})
+
+	case sub == "" || sub == "rollback":
+		w.WriteHeader(405)
+
+	default:
+		writeJSON(w, 404, errorResponse{Error: "not found"})
+	}
+}
@@ -0,0 +1,106 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"invest/internal/calc"
+	"invest/internal/models"
+	"invest/internal/repository"
+	"net/http"
+	"time"
+)
+
+var errNoFeesToCharge = errors.New("nothing to charge: all fees are zero")
+
+//
+// ========================
+//         FEES
+// ========================
+//
+
+type feeChargeRequest struct {
+	Date   string `json:"date"`
+	DryRun bool   `json:"dryRun"`
+	FundID int64  `json:"fundId"` // 0 — фонд по умолчанию
+}
+
+// POST /api/fees/charge — начислить management и performance комиссии за месяц
+// по позициям одного фонда
+func (s *Server) handleChargeFees(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(405)
+		return
+	}
+
+	var req feeChargeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, 400, errorResponse{Error: "invalid json"})
+		return
+	}
+
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		writeJSON(w, 400, errorResponse{Error: "invalid date, must be YYYY-MM-DD"})
+		return
+	}
+
+	fundID, ok := s.resolveFund(ctx, w, req.FundID)
+	if !ok {
+		return
+	}
+
+	// предпросмотр: тот же расчёт, но ничего не записываем
+	if req.DryRun {
+		repo := s.repo.InFund(fundID)
+		investors, err := repo.FindInvestors(ctx, repository.InvestorFilter{
+			Statuses: []models.InvestorStatus{models.InvestorActive},
+			TermsAt:  &date,
+		})
+		if err != nil {
+			writeJSON(w, 500, errorResponse{Error: err.Error()})
+			return
+		}
+		payouts, err := repo.GetPayouts(ctx)
+		if err != nil {
+			writeJSON(w, 500, errorResponse{Error: err.Error()})
+			return
+		}
+		writeJSON(w, 200, calc.PlanFees(investors, payouts, date))
+		return
+	}
+
+	createdBy := nullableUserID(ctx)
+
+	var plan calc.FeePlan
+	rows, err := s.repo.ChargeFees(ctx, fundID, date,
+		func(investors []models.Investor, payouts []models.Payout) ([]models.Payout, map[int64]models.Decimal, error) {
+			plan = calc.PlanFees(investors, payouts, date)
+			rows := plan.Payouts()
+			if len(rows) == 0 {
+				return nil, nil, errNoFeesToCharge
+			}
+			for i := range rows {
+				rows[i].CreatedBy = createdBy
+			}
+			return rows, plan.HighWaterMarks(), nil
+		})
+	if errors.Is(err, errNoFeesToCharge) {
+		writeJSON(w, 422, errorResponse{Error: err.Error()})
+		return
+	}
+	if errors.Is(err, repository.ErrPeriodClosed) {
+		writeJSON(w, 409, errorResponse{Error: err.Error()})
+		return
+	}
+	if err != nil {
+		writeJSON(w, 500, errorResponse{Error: err.Error()})
+		return
+	}
+
+	writeJSON(w, 201, map[string]any{
+		"payouts": rows,
+		"plan":    plan,
+	})
+}
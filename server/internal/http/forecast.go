@@ -0,0 +1,135 @@
+package http
+
+import (
+	"database/sql"
+	"errors"
+	"invest/internal/calc"
+	"invest/internal/models"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultForecastMonths = 12
+	maxForecastMonths     = 120
+)
+
+//
+// ========================
+//       FORECAST
+// ========================
+//
+
+// GET /api/investors/{id}/forecast?grossPercent=3&months=12&reinvestRatio=100
+// Прогноз капитала по месяцам от текущего капитала. Необязательно:
+// topup / withdrawal — ежемесячно, flow=YYYY-MM:±сумма — разово (можно несколько),
+// from — первый месяц (по умолчанию следующий).
+func (s *Server) handleInvestorForecast(w http.ResponseWriter, r *http.Request, id int64) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(405)
+		return
+	}
+
+	ctx := r.Context()
+
+	repo, ok := s.fundRepo(w, r)
+	if !ok {
+		return
+	}
+
+	in, msg := parseForecastInput(r)
+	if msg != "" {
+		writeJSON(w, 400, errorResponse{Error: msg})
+		return
+	}
+
+	inv, err := repo.GetInvestorByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeJSON(w, 404, errorResponse{Error: "investor not found"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, 500, errorResponse{Error: err.Error()})
+		return
+	}
+
+	payouts, err := repo.GetPayoutsByInvestor(ctx, id)
+	if err != nil {
+		writeJSON(w, 500, errorResponse{Error: err.Error()})
+		return
+	}
+
+	writeJSON(w, 200, calc.CalcForecast(*inv, payouts, in))
+}
+
+func parseForecastInput(r *http.Request) (calc.ForecastInput, string) {
+	q := r.URL.Query()
+
+	in := calc.ForecastInput{
+		Months:       defaultForecastMonths,
+		ReinvestRate: maxProfitShare,
+		Flows:        map[time.Time]models.Decimal{},
+	}
+
+	var err error
+	if in.GrossPercent, err = models.ParseDecimal(q.Get("grossPercent")); err != nil ||
+		in.GrossPercent.Sign() < 0 || in.GrossPercent.Cmp(maxProfitShare) > 0 {
+		return in, "grossPercent must be between 0 and 100"
+	}
+
+	if v := q.Get("months"); v != "" {
+		in.Months, err = strconv.Atoi(v)
+		if err != nil || in.Months < 1 || in.Months > maxForecastMonths {
+			return in, "months must be between 1 and 120"
+		}
+	}
+
+	if v := q.Get("reinvestRatio"); v != "" {
+		in.ReinvestRate, err = models.ParseDecimal(v)
+		if err != nil || in.ReinvestRate.Sign() < 0 || in.ReinvestRate.Cmp(maxProfitShare) > 0 {
+			return in, "reinvestRatio must be between 0 and 100"
+		}
+	}
+
+	if v := q.Get("topup"); v != "" {
+		in.MonthlyTopup, err = models.ParseDecimal(v)
+		if err != nil || in.MonthlyTopup.Sign() < 0 {
+			return in, "topup must be a non-negative amount"
+		}
+	}
+
+	if v := q.Get("withdrawal"); v != "" {
+		in.MonthlyWithdrawal, err = models.ParseDecimal(v)
+		if err != nil || in.MonthlyWithdrawal.Sign() < 0 {
+			return in, "withdrawal must be a non-negative amount"
+		}
+	}
+
+	// разовые движения: flow=YYYY-MM:сумма, + пополнение, − снятие
+	for _, v := range q["flow"] {
+		monthStr, amountStr, ok := strings.Cut(v, ":")
+		month, err := time.Parse("2006-01", monthStr)
+		if !ok || err != nil {
+			return in, "flow must be YYYY-MM:amount"
+		}
+		amount, err := models.ParseDecimal(amountStr)
+		if err != nil {
+			return in, "flow must be YYYY-MM:amount"
+		}
+		in.Flows[month] = in.Flows[month].Add(amount)
+	}
+
+	from, err := parseDateParam(r, "from")
+	if err != nil {
+		return in, "invalid from, must be YYYY-MM-DD"
+	}
+	if from.IsZero() {
+		now := time.Now().UTC()
+		from = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1, 0)
+	}
+	in.Start = from
+
+	return in, ""
+}
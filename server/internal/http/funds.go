@@ -0,0 +1,273 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"invest/internal/models"
+	"invest/internal/repository"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+//
+// ========================
+//         FUNDS
+// ========================
+//
+
+// GET / POST /api/funds
+func (s *Server) handleFunds(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	switch r.Method {
+
+	case http.MethodGet:
+		list, err := s.repo.ListFunds(ctx)
+		if err != nil {
+			writeJSON(w, 500, errorResponse{Error: err.Error()})
+			return
+		}
+		writeJSON(w, 200, list)
+
+	case http.MethodPost:
+		var req struct {
+			Name string `json:"name"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, 400, errorResponse{Error: "invalid json"})
+			return
+		}
+
+		f := models.Fund{Name: strings.TrimSpace(req.Name)}
+		if f.Name == "" {
+			writeJSON(w, 400, errorResponse{Error: "name required"})
+			return
+		}
+
+		err := s.repo.CreateFund(ctx, &f)
+		if errors.Is(err, repository.ErrFundExists) {
+			writeJSON(w, 409, errorResponse{Error: err.Error()})
+			return
+		}
+		if err != nil {
+			writeJSON(w, 500, errorResponse{Error: err.Error()})
+			return
+		}
+		writeJSON(w, 201, f)
+
+	default:
+		w.WriteHeader(405)
+	}
+}
+
+// /api/funds/{id} и /api/funds/{id}/positions
+func (s *Server) handleFundByID(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/api/funds/")
+	idStr, sub, _ := strings.Cut(rest, "/")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		writeJSON(w, 400, errorResponse{Error: "invalid fund id"})
+		return
+	}
+
+	switch sub {
+	case "":
+		s.handleFund(w, r, id)
+	case "positions":
+		s.handleFundPositions(w, r, id)
+	default:
+		writeJSON(w, 404, errorResponse{Error: "not found"})
+	}
+}
+
+// GET /api/funds/{id} — фонд с позициями участников
+func (s *Server) handleFund(w http.ResponseWriter, r *http.Request, id int64) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(405)
+		return
+	}
+
+	ctx := r.Context()
+
+	f, err := s.repo.GetFund(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeJSON(w, 404, errorResponse{Error: "fund not found"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, 500, errorResponse{Error: err.Error()})
+		return
+	}
+
+	positions, err := s.repo.ListPositions(ctx, id)
+	if err != nil {
+		writeJSON(w, 500, errorResponse{Error: err.Error()})
+		return
+	}
+
+	writeJSON(w, 200, map[string]any{
+		"fund":      f,
+		"positions": positions,
+	})
+}
+
+// GET / POST /api/funds/{id}/positions — участники фонда; POST вводит инвестора в фонд
+func (s *Server) handleFundPositions(w http.ResponseWriter, r *http.Request, fundID int64) {
+	ctx := r.Context()
+
+	switch r.Method {
+
+	case http.MethodGet:
+		list, err := s.repo.ListPositions(ctx, fundID)
+		if err != nil {
+			writeJSON(w, 500, errorResponse{Error: err.Error()})
+			return
+		}
+		writeJSON(w, 200, list)
+
+	case http.MethodPost:
+		var req struct {
+			InvestorID     int64           `json:"investorId"`
+			InvestedAmount models.Decimal  `json:"investedAmount"`
+			ProfitShare    *models.Decimal `json:"profitShare"` // по умолчанию — доля инвестора
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, 400, errorResponse{Error: "invalid json"})
+			return
+		}
+
+		if req.InvestedAmount.Sign() < 0 {
+			writeJSON(w, 400, errorResponse{Error: "investedAmount must be >= 0"})
+			return
+		}
+
+		inv, err := s.repo.GetInvestorByID(ctx, req.InvestorID)
+		if errors.Is(err, sql.ErrNoRows) {
+			writeJSON(w, 404, errorResponse{Error: "investor not found"})
+			return
+		}
+		if err != nil {
+			writeJSON(w, 500, errorResponse{Error: err.Error()})
+			return
+		}
+
+		pos := models.Position{
+			FundID:         fundID,
+			InvestorID:     inv.ID,
+			InvestedAmount: req.InvestedAmount,
+			ProfitShare:    inv.ProfitShare,
+		}
+		if req.ProfitShare != nil {
+			if req.ProfitShare.Sign() <= 0 || req.ProfitShare.Cmp(maxProfitShare) > 0 {
+				writeJSON(w, 400, errorResponse{Error: "profitShare must be between 1 and 100"})
+				return
+			}
+			pos.ProfitShare = *req.ProfitShare
+		}
+
+		err = s.repo.CreatePosition(ctx, &pos)
+		if errors.Is(err, sql.ErrNoRows) {
+			writeJSON(w, 404, errorResponse{Error: "fund not found"})
+			return
+		}
+		if errors.Is(err, repository.ErrPositionExists) {
+			writeJSON(w, 409, errorResponse{Error: err.Error()})
+			return
+		}
+		if err != nil {
+			writeJSON(w, 500, errorResponse{Error: err.Error()})
+			return
+		}
+		writeJSON(w, 201, pos)
+
+	default:
+		w.WriteHeader(405)
+	}
+}
+
+// GET /api/investors/{id}/terms?fundId= — история доли прибыли по позициям;
+// поменять долю с даты — PUT /api/investors/{id} с profit_share_from
+func (s *Server) handleInvestorTerms(w http.ResponseWriter, r *http.Request, id int64) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(405)
+		return
+	}
+
+	ctx := r.Context()
+
+	repo, ok := s.fundRepo(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := repo.GetInvestorByID(ctx, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeJSON(w, 404, errorResponse{Error: "investor not found"})
+			return
+		}
+		writeJSON(w, 500, errorResponse{Error: err.Error()})
+		return
+	}
+
+	terms, err := repo.ListProfitShareTerms(ctx, id)
+	if err != nil {
+		writeJSON(w, 500, errorResponse{Error: err.Error()})
+		return
+	}
+
+	writeJSON(w, 200, terms)
+}
+
+// parseFundID — необязательный ?fundId= (0, если нет)
+func parseFundID(r *http.Request) (int64, error) {
+	v := r.URL.Query().Get("fundId")
+	if v == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(v, 10, 64)
+}
+
+// fundRepo — репозиторий с выборками по фонду из ?fundId= (без него — все фонды);
+// false — параметр неверный, ответ уже записан
+func (s *Server) fundRepo(w http.ResponseWriter, r *http.Request) (*repository.Repository, bool) {
+	fundID, err := parseFundID(r)
+	if err != nil || fundID < 0 {
+		writeJSON(w, 400, errorResponse{Error: "invalid fundId"})
+		return nil, false
+	}
+	return s.repo.InFund(fundID), true
+}
+
+// singleFundRepo — репозиторий одного фонда из ?fundId= (без него — фонд
+// по умолчанию) для данных, у которых нет сводки по всем фондам (паи, NAV);
+// false — параметр неверный или фонда нет, ответ уже записан
+func (s *Server) singleFundRepo(w http.ResponseWriter, r *http.Request) (*repository.Repository, bool) {
+	fundID, err := parseFundID(r)
+	if err != nil || fundID < 0 {
+		writeJSON(w, 400, errorResponse{Error: "invalid fundId"})
+		return nil, false
+	}
+	fundID, ok := s.resolveFund(r.Context(), w, fundID)
+	if !ok {
+		return nil, false
+	}
+	return s.repo.InFund(fundID), true
+}
+
+// resolveFund — фонд для записи: указанный или по умолчанию (0);
+// false — фонда нет, ответ уже записан
+func (s *Server) resolveFund(ctx context.Context, w http.ResponseWriter, fundID int64) (int64, bool) {
+	id, err := s.repo.ResolveFundID(ctx, fundID)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeJSON(w, 404, errorResponse{Error: "fund not found"})
+		return 0, false
+	}
+	if err != nil {
+		writeJSON(w, 500, errorResponse{Error: err.Error()})
+		return 0, false
+	}
+	return id, true
+}
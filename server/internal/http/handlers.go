@@ -1,14 +1,23 @@
 package http
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"invest/internal/calc"
 	"invest/internal/models"
+	"invest/internal/repository"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 )
 
+var (
+	defaultProfitShare = models.DecimalFromInt(50)
+	maxProfitShare     = models.DecimalFromInt(100)
+)
+
 type errorResponse struct {
 	Error string `json:"error"`
 }
@@ -19,6 +28,54 @@ func writeJSON(w http.ResponseWriter, code int, v any) {
 	_ = json.NewEncoder(w).Encode(v)
 }
 
+// validateFeePercent — комиссии от 0 до 100%; nil не проверяется
+func validateFeePercent(management, performance *models.Decimal) string {
+	if management != nil && (management.Sign() < 0 || management.Cmp(maxProfitShare) > 0) {
+		return "management_fee_percent must be between 0 and 100"
+	}
+	if performance != nil && (performance.Sign() < 0 || performance.Cmp(maxProfitShare) > 0) {
+		return "performance_fee_percent must be between 0 and 100"
+	}
+	return ""
+}
+
+// normalizeTags — теги без пробелов по краям, в нижнем регистре, без пустых и повторов
+func normalizeTags(tags []string) []string {
+	out := []string{}
+	seen := map[string]bool{}
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	return out
+}
+
+// normalizeContacts обрезает пробелы, убирает @ у Telegram и проверяет email;
+// nil не трогает
+func normalizeContacts(phone, email, telegram, address *string) string {
+	for _, v := range []*string{phone, email, telegram, address} {
+		if v != nil {
+			*v = strings.TrimSpace(*v)
+		}
+	}
+	if telegram != nil {
+		*telegram = strings.TrimPrefix(*telegram, "@")
+	}
+	if email != nil && *email != "" && !strings.Contains(*email, "@") {
+		return "invalid email"
+	}
+	return ""
+}
+
+// isLimitError — снятие больше доступного капитала или прибыли (422)
+func isLimitError(err error) bool {
+	return errors.Is(err, calc.ErrCapitalExceeded) || errors.Is(err, calc.ErrProfitExceeded)
+}
+
 //
 // ========================
 //      INVESTORS
@@ -30,8 +87,30 @@ func (s *Server) handleInvestors(w http.ResponseWriter, r *http.Request) {
 
 	switch r.Method {
 
+	// ?status=active,suspended — фильтр; без него — все, кроме архивных; all — вообще все.
+	// ?tag=a&tag=b (или tag=a,b) — только инвесторы со всеми этими тегами.
+	// ?fundId= — участники фонда с суммой и долей их позиции
 	case http.MethodGet:
-		list, err := s.repo.ListInvestors(ctx)
+		repo, ok := s.fundRepo(w, r)
+		if !ok {
+			return
+		}
+
+		statuses, msg := parseInvestorStatuses(r.URL.Query().Get("status"))
+		if msg != "" {
+			writeJSON(w, 400, errorResponse{Error: msg})
+			return
+		}
+
+		var tags []string
+		for _, v := range r.URL.Query()["tag"] {
+			tags = append(tags, strings.Split(v, ",")...)
+		}
+
+		list, err := repo.FindInvestors(ctx, repository.InvestorFilter{
+			Statuses: statuses,
+			Tags:     normalizeTags(tags),
+		})
 		if err != nil {
 			writeJSON(w, 500, errorResponse{Error: err.Error()})
 			return
@@ -39,29 +118,61 @@ func (s *Server) handleInvestors(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, 200, list)
 
 	case http.MethodPost:
-		var inv models.Investor
-		if err := json.NewDecoder(r.Body).Decode(&inv); err != nil {
+		var req struct {
+			models.Investor
+
+			// без поля — резидент (как DEFAULT в таблице)
+			TaxResident *bool `json:"tax_resident"`
+
+			// фонд первой позиции (invested_amount, profit_share); 0 — по умолчанию
+			FundID int64 `json:"fund_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			writeJSON(w, 400, errorResponse{Error: "invalid json"})
 			return
 		}
 
+		inv := req.Investor
+		inv.TaxResident = req.TaxResident == nil || *req.TaxResident
+
 		// defaults
 		if inv.FullName == "" {
 			inv.FullName = ""
 		}
 
 		// validation
-		if inv.InvestedAmount < 0 {
+		if inv.InvestedAmount.Sign() < 0 {
 			writeJSON(w, 400, errorResponse{Error: "invested_amount must be >= 0"})
 			return
 		}
 
 		// ✅ profit_share default + validation
-		if inv.ProfitShare <= 0 || inv.ProfitShare > 100 {
-			inv.ProfitShare = 50
+		if inv.ProfitShare.Sign() <= 0 || inv.ProfitShare.Cmp(maxProfitShare) > 0 {
+			inv.ProfitShare = defaultProfitShare
+		}
+
+		if msg := validateFeePercent(&inv.ManagementFeePercent, &inv.PerformanceFeePercent); msg != "" {
+			writeJSON(w, 400, errorResponse{Error: msg})
+			return
+		}
+
+		if inv.TaxRate.Sign() < 0 || inv.TaxRate.Cmp(maxProfitShare) > 0 {
+			writeJSON(w, 400, errorResponse{Error: "tax_rate must be between 0 and 100"})
+			return
+		}
+
+		if msg := normalizeContacts(&inv.Phone, &inv.Email, &inv.Telegram, &inv.Address); msg != "" {
+			writeJSON(w, 400, errorResponse{Error: msg})
+			return
 		}
+		inv.Tags = normalizeTags(inv.Tags)
 
-		if err := s.repo.CreateInvestor(ctx, &inv); err != nil {
+		err := s.repo.CreateInvestor(ctx, &inv, req.FundID)
+		if errors.Is(err, sql.ErrNoRows) {
+			writeJSON(w, 404, errorResponse{Error: "fund not found"})
+			return
+		}
+		if err != nil {
 			writeJSON(w, 500, errorResponse{Error: err.Error()})
 			return
 		}
@@ -76,20 +187,77 @@ func (s *Server) handleInvestors(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleInvestorByID(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	idStr := strings.TrimPrefix(r.URL.Path, "/api/investors/")
+	rest := strings.TrimPrefix(r.URL.Path, "/api/investors/")
+	idStr, sub, _ := strings.Cut(rest, "/")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
 		writeJSON(w, 400, errorResponse{Error: "invalid investor id"})
 		return
 	}
 
+	// вложенные ресурсы: /api/investors/{id}/...
+	switch sub {
+	case "":
+	case "summary":
+		s.handleInvestorSummary(w, r, id)
+		return
+	case "performance":
+		s.handleInvestorPerformance(w, r, id)
+		return
+	case "units":
+		s.handleInvestorUnits(w, r, id)
+		return
+	case "forecast":
+		s.handleInvestorForecast(w, r, id)
+		return
+	case "capital-base":
+		s.handleInvestorCapitalBase(w, r, id)
+		return
+	case "terms":
+		s.handleInvestorTerms(w, r, id)
+		return
+	case "purge":
+		s.withAdmin(func(w http.ResponseWriter, r *http.Request) {
+			s.handleInvestorPurge(w, r, id)
+		})(w, r)
+		return
+	default:
+		writeJSON(w, 404, errorResponse{Error: "not found"})
+		return
+	}
+
 	switch r.Method {
 
 	case http.MethodPut:
 		var req struct {
-			FullName       *string  `json:"full_name"`
-			InvestedAmount *float64 `json:"invested_amount"`
-			ProfitShare    *float64 `json:"profit_share"` // ✅ новое поле
+			FullName       *string         `json:"full_name"`
+			InvestedAmount *models.Decimal `json:"invested_amount"`
+			ProfitShare    *models.Decimal `json:"profit_share"` // ✅ новое поле
+
+			// с какой даты действует новая profit_share, YYYY-MM-DD; по умолчанию — сегодня
+			ProfitShareFrom *string `json:"profit_share_from"`
+
+			// с какой даты действует новая invested_amount, YYYY-MM-DD; по умолчанию — сегодня
+			InvestedAmountFrom *string `json:"invested_amount_from"`
+
+			// чья позиция получает invested_amount и profit_share; 0 — фонд по умолчанию
+			FundID int64 `json:"fund_id"`
+
+			ManagementFeePercent  *models.Decimal `json:"management_fee_percent"`
+			PerformanceFeePercent *models.Decimal `json:"performance_fee_percent"`
+
+			TaxRate     *models.Decimal `json:"tax_rate"`
+			TaxResident *bool           `json:"tax_resident"`
+
+			Status   *models.InvestorStatus `json:"status"`
+			ClosedAt *string                `json:"closed_at"` // YYYY-MM-DD, для closed/archived
+
+			Phone    *string   `json:"phone"`
+			Email    *string   `json:"email"`
+			Telegram *string   `json:"telegram"`
+			Address  *string   `json:"address"`
+			Notes    *string   `json:"notes"`
+			Tags     *[]string `json:"tags"` // заменяет список целиком
 		}
 
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -98,25 +266,132 @@ func (s *Server) handleInvestorByID(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// validation
-		if req.InvestedAmount != nil && *req.InvestedAmount < 0 {
+		if req.InvestedAmount != nil && req.InvestedAmount.Sign() < 0 {
 			writeJSON(w, 400, errorResponse{Error: "invested_amount must be >= 0"})
 			return
 		}
 
 		if req.ProfitShare != nil {
-			if *req.ProfitShare <= 0 || *req.ProfitShare > 100 {
+			if req.ProfitShare.Sign() <= 0 || req.ProfitShare.Cmp(maxProfitShare) > 0 {
 				writeJSON(w, 400, errorResponse{Error: "profit_share must be between 1 and 100"})
 				return
 			}
 		}
 
-		// ✅ обновляем 3 поля
-		if err := s.repo.UpdateInvestor(ctx, id, req.FullName, req.InvestedAmount, req.ProfitShare); err != nil {
+		if msg := validateFeePercent(req.ManagementFeePercent, req.PerformanceFeePercent); msg != "" {
+			writeJSON(w, 400, errorResponse{Error: msg})
+			return
+		}
+
+		if req.TaxRate != nil && (req.TaxRate.Sign() < 0 || req.TaxRate.Cmp(maxProfitShare) > 0) {
+			writeJSON(w, 400, errorResponse{Error: "tax_rate must be between 0 and 100"})
+			return
+		}
+
+		if req.Status != nil && !req.Status.Valid() {
+			writeJSON(w, 400, errorResponse{Error: "status must be active, suspended, closed or archived"})
+			return
+		}
+
+		if msg := normalizeContacts(req.Phone, req.Email, req.Telegram, req.Address); msg != "" {
+			writeJSON(w, 400, errorResponse{Error: msg})
+			return
+		}
+		if req.Tags != nil {
+			tags := normalizeTags(*req.Tags)
+			req.Tags = &tags
+		}
+
+		var closedAt *time.Time
+		if req.ClosedAt != nil {
+			if req.Status == nil || !req.Status.Ended() {
+				writeJSON(w, 400, errorResponse{Error: "closed_at requires status closed or archived"})
+				return
+			}
+			d, err := time.Parse("2006-01-02", *req.ClosedAt)
+			if err != nil {
+				writeJSON(w, 400, errorResponse{Error: "invalid closed_at, must be YYYY-MM-DD"})
+				return
+			}
+			closedAt = &d
+		}
+
+		var shareFrom *time.Time
+		if req.ProfitShareFrom != nil {
+			if req.ProfitShare == nil {
+				writeJSON(w, 400, errorResponse{Error: "profit_share_from requires profit_share"})
+				return
+			}
+			d, err := time.Parse("2006-01-02", *req.ProfitShareFrom)
+			if err != nil {
+				writeJSON(w, 400, errorResponse{Error: "invalid profit_share_from, must be YYYY-MM-DD"})
+				return
+			}
+			shareFrom = &d
+		}
+
+		var amountFrom *time.Time
+		if req.InvestedAmountFrom != nil {
+			if req.InvestedAmount == nil {
+				writeJSON(w, 400, errorResponse{Error: "invested_amount_from requires invested_amount"})
+				return
+			}
+			d, err := time.Parse("2006-01-02", *req.InvestedAmountFrom)
+			if err != nil {
+				writeJSON(w, 400, errorResponse{Error: "invalid invested_amount_from, must be YYYY-MM-DD"})
+				return
+			}
+			amountFrom = &d
+		}
+
+		patch := repository.InvestorPatch{
+			FundID:                req.FundID,
+			FullName:              req.FullName,
+			InvestedAmount:        req.InvestedAmount,
+			ProfitShare:           req.ProfitShare,
+			ManagementFeePercent:  req.ManagementFeePercent,
+			PerformanceFeePercent: req.PerformanceFeePercent,
+			TaxRate:               req.TaxRate,
+			TaxResident:           req.TaxResident,
+			Status:                req.Status,
+			ClosedAt:              closedAt,
+			Phone:                 req.Phone,
+			Email:                 req.Email,
+			Telegram:              req.Telegram,
+			Address:               req.Address,
+			Notes:                 req.Notes,
+			Tags:                  req.Tags,
+			ProfitShareFrom:       shareFrom,
+			InvestedAmountFrom:    amountFrom,
+			ChangedBy:             nullableUserID(ctx),
+		}
+		err := s.repo.UpdateInvestor(ctx, id, patch)
+		if errors.Is(err, sql.ErrNoRows) {
+			writeJSON(w, 404, errorResponse{Error: "investor not found"})
+			return
+		}
+		if errors.Is(err, repository.ErrFundNotFound) {
+			writeJSON(w, 404, errorResponse{Error: err.Error()})
+			return
+		}
+		if errors.Is(err, repository.ErrNoPosition) {
+			writeJSON(w, 422, errorResponse{Error: err.Error()})
+			return
+		}
+		if errors.Is(err, repository.ErrPeriodClosed) {
+			writeJSON(w, 409, errorResponse{Error: err.Error()})
+			return
+		}
+		if err != nil {
 			writeJSON(w, 500, errorResponse{Error: err.Error()})
 			return
 		}
 
 		inv, err := s.repo.GetInvestorByID(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			writeJSON(w, 404, errorResponse{Error: "investor not found"})
+			return
+		}
 		if err != nil {
 			writeJSON(w, 500, errorResponse{Error: err.Error()})
 			return
@@ -124,19 +399,73 @@ func (s *Server) handleInvestorByID(w http.ResponseWriter, r *http.Request) {
 
 		writeJSON(w, 200, inv)
 
+	// история операций остаётся; удалить совсем — POST /api/investors/{id}/purge
 	case http.MethodDelete:
-		if err := s.repo.DeleteInvestor(ctx, id); err != nil {
+		err := s.repo.ArchiveInvestor(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			writeJSON(w, 404, errorResponse{Error: "investor not found"})
+			return
+		}
+		if err != nil {
 			writeJSON(w, 500, errorResponse{Error: err.Error()})
 			return
 		}
 
-		writeJSON(w, 200, map[string]string{"message": "deleted"})
+		writeJSON(w, 200, map[string]string{"message": "archived"})
 
 	default:
 		w.WriteHeader(405)
 	}
 }
 
+// POST /api/investors/{id}/purge — физическое удаление архивного инвестора
+// со всеми операциями (только администратор)
+func (s *Server) handleInvestorPurge(w http.ResponseWriter, r *http.Request, id int64) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(405)
+		return
+	}
+
+	err := s.repo.PurgeInvestor(r.Context(), id)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		writeJSON(w, 404, errorResponse{Error: "investor not found"})
+		return
+	case errors.Is(err, repository.ErrInvestorNotArchived),
+		errors.Is(err, repository.ErrPeriodClosed):
+		writeJSON(w, 409, errorResponse{Error: err.Error()})
+		return
+	case err != nil:
+		writeJSON(w, 500, errorResponse{Error: err.Error()})
+		return
+	}
+
+	writeJSON(w, 200, map[string]string{"message": "purged"})
+}
+
+// parseInvestorStatuses разбирает ?status=a,b.
+// Пусто — все, кроме архивных; "all" — nil (без фильтра).
+func parseInvestorStatuses(v string) ([]models.InvestorStatus, string) {
+	if v == "" {
+		return []models.InvestorStatus{
+			models.InvestorActive, models.InvestorSuspended, models.InvestorClosed,
+		}, ""
+	}
+	if v == "all" {
+		return nil, ""
+	}
+
+	var out []models.InvestorStatus
+	for _, part := range strings.Split(v, ",") {
+		st := models.InvestorStatus(strings.TrimSpace(part))
+		if !st.Valid() {
+			return nil, "invalid status: " + string(st)
+		}
+		out = append(out, st)
+	}
+	return out, ""
+}
+
 //
 // ========================
 //      TOPUP (ПОПОЛНЕНИЕ)
@@ -150,9 +479,10 @@ func (s *Server) handleTopup(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		InvestorID int64   `json:"investorId"`
-		Date       string  `json:"date"`
-		Amount     float64 `json:"amount"`
+		InvestorID int64          `json:"investorId"`
+		FundID     int64          `json:"fundId"` // 0 — фонд по умолчанию
+		Date       string         `json:"date"`
+		Amount     models.Decimal `json:"amount"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -160,7 +490,7 @@ func (s *Server) handleTopup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Amount <= 0 {
+	if req.Amount.Sign() <= 0 {
 		writeJSON(w, 400, errorResponse{Error: "amount must be > 0"})
 		return
 	}
@@ -171,15 +501,36 @@ func (s *Server) handleTopup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	fundID, ok := s.resolveFund(r.Context(), w, req.FundID)
+	if !ok {
+		return
+	}
+
 	payout := models.Payout{
 		InvestorID:   req.InvestorID,
-		PeriodMonth:  nil,     // старое поле НЕ ЗАПОЛНЯЕМ
-		PeriodDate:   &period, // новое поле
+		FundID:       fundID,
+		PeriodDate:   period,
 		PayoutAmount: req.Amount,
+		Type:         models.PayoutTopup,
 		IsTopup:      true,
+		CreatedBy:    nullableUserID(r.Context()),
 	}
 
-	if err := s.repo.CreateTopup(r.Context(), &payout); err != nil {
+	err = s.repo.CreateTopup(r.Context(), &payout)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeJSON(w, 404, errorResponse{Error: "investor not found"})
+		return
+	}
+	if errors.Is(err, repository.ErrNoPosition) {
+		writeJSON(w, 422, errorResponse{Error: err.Error()})
+		return
+	}
+	if errors.Is(err, repository.ErrPeriodClosed) ||
+		errors.Is(err, repository.ErrInvestorArchived) {
+		writeJSON(w, 409, errorResponse{Error: err.Error()})
+		return
+	}
+	if err != nil {
 		writeJSON(w, 500, errorResponse{Error: err.Error()})
 		return
 	}
@@ -193,13 +544,106 @@ func (s *Server) handleTopup(w http.ResponseWriter, r *http.Request) {
 // ========================
 //
 
+type payoutRequest struct {
+	InvestorID          int64          `json:"investorId"`
+	Date                string         `json:"date"`
+	PayoutAmount        models.Decimal `json:"payoutAmount"`
+	Type                string         `json:"type"`
+	FeeKind             string         `json:"feeKind"` // для type=fee, по умолчанию management
+	FundID              int64          `json:"fundId"`  // 0 — фонд по умолчанию (при правке — прежний)
+	NoticeDate          string         `json:"noticeDate"` // для снятия капитала: когда уведомил инвестор, по умолчанию сегодня
+
+	// старый формат: тип по флагам, если type не передан
+	Reinvest            bool           `json:"reinvest"`
+	IsWithdrawalProfit  bool           `json:"isWithdrawalProfit"`
+	IsWithdrawalCapital bool           `json:"isWithdrawalCapital"`
+	IsTopup             bool           `json:"isTopup"`
+}
+
+// toPayout — общая проверка для создания и редактирования выплаты
+func (req payoutRequest) toPayout() (models.Payout, string) {
+	if req.PayoutAmount.IsZero() {
+		return models.Payout{}, "payoutAmount must not be 0"
+	}
+
+	typ := models.PayoutType(req.Type)
+	if typ == "" {
+		var ok bool
+		typ, ok = models.PayoutTypeFromFlags(
+			req.Reinvest, req.IsWithdrawalProfit, req.IsWithdrawalCapital, req.IsTopup)
+		if !ok {
+			return models.Payout{}, "conflicting payout flags"
+		}
+	}
+	if !typ.Valid() {
+		return models.Payout{}, "invalid payout type"
+	}
+
+	switch {
+	// ✅ если снимаем капитал — обязано быть отрицательным
+	case typ == models.PayoutCapitalWithdrawal && req.PayoutAmount.Sign() > 0:
+		req.PayoutAmount = req.PayoutAmount.Neg()
+
+	// корректировка может быть любого знака
+	case typ == models.PayoutAdjustment:
+
+	// ✅ если НЕ снимаем капитал — обязано быть положительным
+	case typ != models.PayoutCapitalWithdrawal && req.PayoutAmount.Sign() < 0:
+		req.PayoutAmount = req.PayoutAmount.Neg()
+	}
+
+	period, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		return models.Payout{}, "invalid date, must be YYYY-MM-DD"
+	}
+
+	p := models.Payout{
+		InvestorID:   req.InvestorID,
+		FundID:       req.FundID,
+		PeriodDate:   period,
+		PayoutAmount: req.PayoutAmount,
+		Type:         typ,
+	}
+
+	if req.NoticeDate != "" {
+		if typ != models.PayoutCapitalWithdrawal {
+			return models.Payout{}, "noticeDate is only for capital_withdrawal"
+		}
+		notice, err := time.Parse("2006-01-02", req.NoticeDate)
+		if err != nil {
+			return models.Payout{}, "invalid noticeDate, must be YYYY-MM-DD"
+		}
+		p.NoticeDate = &notice
+	}
+
+	if typ == models.PayoutFee {
+		kind := models.FeeManagement
+		if req.FeeKind != "" {
+			kind = models.FeeKind(req.FeeKind)
+		}
+		if !kind.Valid() {
+			return models.Payout{}, "feeKind must be management, performance or early_withdrawal"
+		}
+		p.FeeKind = &kind
+	}
+	p.SyncFlags()
+
+	return p, ""
+}
+
 func (s *Server) handlePayouts(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	switch r.Method {
 
+	// ?fundId= — операции одного фонда
 	case http.MethodGet:
-		list, err := s.repo.GetPayouts(ctx)
+		repo, ok := s.fundRepo(w, r)
+		if !ok {
+			return
+		}
+
+		list, err := repo.GetPayouts(ctx)
 		if err != nil {
 			writeJSON(w, 500, errorResponse{Error: err.Error()})
 			return
@@ -207,53 +651,50 @@ func (s *Server) handlePayouts(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, 200, list)
 
 	case http.MethodPost:
-		var req struct {
-			InvestorID          int64   `json:"investorId"`
-			Date                string  `json:"date"`
-			PayoutAmount        float64 `json:"payoutAmount"`
-			Reinvest            bool    `json:"reinvest"`
-			IsWithdrawalProfit  bool    `json:"isWithdrawalProfit"`
-			IsWithdrawalCapital bool    `json:"isWithdrawalCapital"`
-		}
+		var req payoutRequest
 
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			writeJSON(w, 400, errorResponse{Error: "invalid json"})
 			return
 		}
 
-	if req.PayoutAmount == 0 {
-	writeJSON(w, 400, errorResponse{Error: "payoutAmount must not be 0"})
-	return
-}
-
-// ✅ если снимаем капитал — обязано быть отрицательным
-if req.IsWithdrawalCapital && req.PayoutAmount > 0 {
-	req.PayoutAmount = -req.PayoutAmount
-}
-
-// ✅ если НЕ снимаем капитал — обязано быть положительным
-if !req.IsWithdrawalCapital && req.PayoutAmount < 0 {
-	req.PayoutAmount = -req.PayoutAmount
-}
-
-		period, err := time.Parse("2006-01-02", req.Date)
-		if err != nil {
-			writeJSON(w, 400, errorResponse{Error: "invalid date, must be YYYY-MM-DD"})
+		p, msg := req.toPayout()
+		if msg != "" {
+			writeJSON(w, 400, errorResponse{Error: msg})
+			return
+		}
+		// пополнения — через /api/payouts/topup
+		if p.Type == models.PayoutTopup {
+			writeJSON(w, 400, errorResponse{Error: "use /api/payouts/topup for top-ups"})
 			return
 		}
+		p.CreatedBy = nullableUserID(ctx)
 
-		p := models.Payout{
-			InvestorID:          req.InvestorID,
-			PeriodMonth:         nil,     // старое поле не используется
-			PeriodDate:          &period, // новое поле
-			PayoutAmount:        req.PayoutAmount,
-			Reinvest:            req.Reinvest,
-			IsWithdrawalProfit:  req.IsWithdrawalProfit,
-			IsWithdrawalCapital: req.IsWithdrawalCapital,
-			IsTopup:             false,
+		fundID, ok := s.resolveFund(ctx, w, p.FundID)
+		if !ok {
+			return
 		}
+		p.FundID = fundID
 
-		if err := s.repo.CreatePayout(ctx, &p); err != nil {
+		// снятие не больше текущего капитала / накопленной прибыли позиции;
+		// снятие капитала — ещё и по договору (уведомление, штраф в lock-up)
+		err := s.repo.CreatePayout(ctx, &p, calc.CheckWithdrawal)
+		if errors.Is(err, sql.ErrNoRows) {
+			writeJSON(w, 404, errorResponse{Error: "investor not found"})
+			return
+		}
+		if isLimitError(err) || errors.Is(err, repository.ErrNoPosition) ||
+			errors.Is(err, repository.ErrNoticeTooShort) ||
+			errors.Is(err, repository.ErrNoticeRequired) {
+			writeJSON(w, 422, errorResponse{Error: err.Error()})
+			return
+		}
+		if errors.Is(err, repository.ErrPeriodClosed) ||
+			errors.Is(err, repository.ErrInvestorArchived) {
+			writeJSON(w, 409, errorResponse{Error: err.Error()})
+			return
+		}
+		if err != nil {
 			writeJSON(w, 500, errorResponse{Error: err.Error()})
 			return
 		}
@@ -0,0 +1,247 @@
+package http
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"invest/internal/calc"
+	"invest/internal/models"
+	"invest/internal/repository"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//
+// ========================
+//      PAYOUT BY ID
+// ========================
+//
+
+// /api/payouts/{id}/...
+func (s *Server) handlePayoutByID(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/api/payouts/")
+	idStr, sub, _ := strings.Cut(rest, "/")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		writeJSON(w, 400, errorResponse{Error: "invalid payout id"})
+		return
+	}
+
+	switch sub {
+	case "":
+		s.handlePayout(w, r, id)
+	case "reverse":
+		s.handlePayoutReverse(w, r, id)
+	case "revisions":
+		s.handlePayoutRevisions(w, r, id)
+	default:
+		writeJSON(w, 404, errorResponse{Error: "not found"})
+	}
+}
+
+// GET / PUT / DELETE /api/payouts/{id}
+func (s *Server) handlePayout(w http.ResponseWriter, r *http.Request, id int64) {
+	ctx := r.Context()
+
+	switch r.Method {
+
+	case http.MethodGet:
+		p, err := s.repo.GetPayoutByID(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			writeJSON(w, 404, errorResponse{Error: "payout not found"})
+			return
+		}
+		if err != nil {
+			writeJSON(w, 500, errorResponse{Error: err.Error()})
+			return
+		}
+		writeJSON(w, 200, p)
+
+	case http.MethodPut:
+		var req payoutRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, 400, errorResponse{Error: "invalid json"})
+			return
+		}
+
+		cur, err := s.repo.GetPayoutByID(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			writeJSON(w, 404, errorResponse{Error: "payout not found"})
+			return
+		}
+		if err != nil {
+			writeJSON(w, 500, errorResponse{Error: err.Error()})
+			return
+		}
+
+		p, msg := req.editPayout(*cur)
+		if msg != "" {
+			writeJSON(w, 400, errorResponse{Error: msg})
+			return
+		}
+
+		// перенос в другой фонд — только в существующий
+		if p.FundID != 0 {
+			if _, ok := s.resolveFund(ctx, w, p.FundID); !ok {
+				return
+			}
+		}
+
+		err = s.repo.UpdatePayout(ctx, &p, userIDFromContext(ctx), calc.CheckWithdrawal)
+		if !s.writePayoutChangeError(w, err) {
+			return
+		}
+		writeJSON(w, 200, p)
+
+	case http.MethodDelete:
+		err := s.repo.DeletePayout(ctx, id, userIDFromContext(ctx))
+		if !s.writePayoutChangeError(w, err) {
+			return
+		}
+		writeJSON(w, 200, map[string]string{"message": "deleted"})
+
+	default:
+		w.WriteHeader(405)
+	}
+}
+
+// editPayout — выплата для PUT: непереданные поля остаются как в cur,
+// дальше те же правила, что и при создании (включая знак для снятия капитала)
+func (req payoutRequest) editPayout(cur models.Payout) (models.Payout, string) {
+	if req.InvestorID == 0 {
+		req.InvestorID = cur.InvestorID
+	}
+	if req.Date == "" {
+		req.Date = cur.PeriodDate.Format("2006-01-02")
+	}
+	if req.PayoutAmount.IsZero() {
+		req.PayoutAmount = cur.PayoutAmount
+	}
+
+	// без type и флагов тип не меняется: иначе правка одной даты
+	// превратила бы реинвест или снятие в корректировку
+	noFlags := !req.Reinvest && !req.IsWithdrawalProfit && !req.IsWithdrawalCapital && !req.IsTopup
+	if req.Type == "" && noFlags {
+		req.Type = string(cur.Kind())
+	}
+	if req.Type == string(models.PayoutFee) && req.FeeKind == "" && cur.FeeKind != nil {
+		req.FeeKind = string(*cur.FeeKind)
+	}
+	if req.NoticeDate == "" && cur.NoticeDate != nil && req.Type == string(models.PayoutCapitalWithdrawal) {
+		req.NoticeDate = cur.NoticeDate.Format("2006-01-02")
+	}
+
+	p, msg := req.toPayout()
+	if msg != "" {
+		return p, msg
+	}
+	p.ID = cur.ID
+
+	// операцию нельзя превратить в пополнение — как и в POST
+	if p.Type == models.PayoutTopup && cur.Kind() != models.PayoutTopup {
+		return p, "use /api/payouts/topup for top-ups"
+	}
+	return p, ""
+}
+
+// writePayoutChangeError пишет ответ с ошибкой; false — если ошибка была
+func (s *Server) writePayoutChangeError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, sql.ErrNoRows):
+		writeJSON(w, 404, errorResponse{Error: "payout not found"})
+	case errors.Is(err, repository.ErrPayoutLocked),
+		errors.Is(err, repository.ErrTaxEntryLinked),
+		errors.Is(err, repository.ErrPenaltyEntryLinked),
+		errors.Is(err, repository.ErrLaterPerformanceFee),
+		errors.Is(err, repository.ErrInvestorArchived),
+		errors.Is(err, repository.ErrPeriodClosed):
+		writeJSON(w, 409, errorResponse{Error: err.Error()})
+	case isLimitError(err), errors.Is(err, repository.ErrNoPosition),
+		errors.Is(err, repository.ErrNoticeTooShort),
+		errors.Is(err, repository.ErrNoticeRequired):
+		writeJSON(w, 422, errorResponse{Error: err.Error()})
+	default:
+		writeJSON(w, 500, errorResponse{Error: err.Error()})
+	}
+	return false
+}
+
+// GET /api/payouts/{id}/revisions — кто, что и когда менял
+func (s *Server) handlePayoutRevisions(w http.ResponseWriter, r *http.Request, id int64) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(405)
+		return
+	}
+
+	list, err := s.repo.ListPayoutRevisions(r.Context(), id)
+	if err != nil {
+		writeJSON(w, 500, errorResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, 200, list)
+}
+
+// POST /api/payouts/{id}/reverse — сторно ошибочной записи
+func (s *Server) handlePayoutReverse(w http.ResponseWriter, r *http.Request, id int64) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(405)
+		return
+	}
+
+	ctx := r.Context()
+
+	var req struct {
+		Reason string `json:"reason"`
+		Date   string `json:"date"` // необязательно, по умолчанию — дата исходной записи
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, 400, errorResponse{Error: "invalid json"})
+		return
+	}
+
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		writeJSON(w, 400, errorResponse{Error: "reason required"})
+		return
+	}
+
+	var date *time.Time
+	if req.Date != "" {
+		d, err := time.Parse("2006-01-02", req.Date)
+		if err != nil {
+			writeJSON(w, 400, errorResponse{Error: "invalid date, must be YYYY-MM-DD"})
+			return
+		}
+		date = &d
+	}
+
+	// сторно пополнения не может увести капитал в минус
+	rev, err := s.repo.ReversePayout(ctx, id, req.Reason, date, userIDFromContext(ctx), calc.CheckWithdrawal)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		writeJSON(w, 404, errorResponse{Error: "payout not found"})
+		return
+	case isLimitError(err):
+		writeJSON(w, 422, errorResponse{Error: err.Error()})
+		return
+	case errors.Is(err, repository.ErrPayoutAlreadyReversed),
+		errors.Is(err, repository.ErrInvestorArchived),
+		errors.Is(err, repository.ErrCannotReverseReversal),
+		errors.Is(err, repository.ErrTaxEntryLinked),
+		errors.Is(err, repository.ErrPenaltyEntryLinked),
+		errors.Is(err, repository.ErrLaterPerformanceFee),
+		errors.Is(err, repository.ErrDistributionPayout),
+		errors.Is(err, repository.ErrPeriodClosed):
+		writeJSON(w, 409, errorResponse{Error: err.Error()})
+		return
+	case err != nil:
+		writeJSON(w, 500, errorResponse{Error: err.Error()})
+		return
+	}
+
+	writeJSON(w, 201, rev)
+}
@@ -0,0 +1,102 @@
+package http
+
+import (
+	"encoding/json"
+	"invest/internal/models"
+	"testing"
+	"time"
+)
+
+func TestEditPayout(t *testing.T) {
+	fee := models.FeePerformance
+	notice := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		cur       models.Payout
+		body      string
+		wantType  models.PayoutType
+		wantDate  string
+		wantSum   string
+		wantFee   models.FeeKind
+		wantError string
+	}{
+		{
+			name:     "правка одной даты — реинвест остаётся реинвестом",
+			cur:      models.Payout{ID: 1, InvestorID: 7, PeriodDate: date("2024-01-31"), PayoutAmount: models.DecimalFromInt(5000), Type: models.PayoutReinvest},
+			body:     `{"date":"2024-02-29"}`,
+			wantType: models.PayoutReinvest,
+			wantDate: "2024-02-29",
+			wantSum:  "5000.00",
+		},
+		{
+			name:     "правка суммы снятия — знак остаётся отрицательным",
+			cur:      models.Payout{ID: 2, InvestorID: 7, PeriodDate: date("2024-03-10"), PayoutAmount: models.DecimalFromInt(-20000), Type: models.PayoutCapitalWithdrawal, NoticeDate: &notice},
+			body:     `{"payoutAmount":"15000"}`,
+			wantType: models.PayoutCapitalWithdrawal,
+			wantDate: "2024-03-10",
+			wantSum:  "-15000.00",
+		},
+		{
+			name:     "комиссия сохраняет вид",
+			cur:      models.Payout{ID: 3, InvestorID: 7, PeriodDate: date("2024-03-31"), PayoutAmount: models.DecimalFromInt(300), Type: models.PayoutFee, FeeKind: &fee},
+			body:     `{"date":"2024-04-30"}`,
+			wantType: models.PayoutFee,
+			wantDate: "2024-04-30",
+			wantSum:  "300.00",
+			wantFee:  models.FeePerformance,
+		},
+		{
+			name:     "явный тип меняет тип",
+			cur:      models.Payout{ID: 4, InvestorID: 7, PeriodDate: date("2024-01-31"), PayoutAmount: models.DecimalFromInt(5000), Type: models.PayoutReinvest},
+			body:     `{"type":"profit_withdrawal"}`,
+			wantType: models.PayoutProfitWithdrawal,
+			wantDate: "2024-01-31",
+			wantSum:  "5000.00",
+		},
+		{
+			name:      "в пополнение — только через topup",
+			cur:       models.Payout{ID: 5, InvestorID: 7, PeriodDate: date("2024-01-31"), PayoutAmount: models.DecimalFromInt(5000), Type: models.PayoutReinvest},
+			body:      `{"isTopup":true}`,
+			wantError: "use /api/payouts/topup for top-ups",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req payoutRequest
+			if err := json.Unmarshal([]byte(tt.body), &req); err != nil {
+				t.Fatal(err)
+			}
+
+			p, msg := req.editPayout(tt.cur)
+			if msg != tt.wantError {
+				t.Fatalf("msg = %q, want %q", msg, tt.wantError)
+			}
+			if msg != "" {
+				return
+			}
+
+			if p.ID != tt.cur.ID || p.InvestorID != tt.cur.InvestorID {
+				t.Errorf("id/investor = %d/%d, want %d/%d", p.ID, p.InvestorID, tt.cur.ID, tt.cur.InvestorID)
+			}
+			if p.Type != tt.wantType {
+				t.Errorf("type = %s, want %s", p.Type, tt.wantType)
+			}
+			if got := p.PeriodDate.Format("2006-01-02"); got != tt.wantDate {
+				t.Errorf("date = %s, want %s", got, tt.wantDate)
+			}
+			if got := p.PayoutAmount.String(); got != tt.wantSum {
+				t.Errorf("amount = %s, want %s", got, tt.wantSum)
+			}
+			if tt.wantFee != "" && (p.FeeKind == nil || *p.FeeKind != tt.wantFee) {
+				t.Errorf("fee kind = %v, want %s", p.FeeKind, tt.wantFee)
+			}
+		})
+	}
+}
+
+func date(s string) time.Time {
+	d, _ := time.Parse("2006-01-02", s)
+	return d
+}
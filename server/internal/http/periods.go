@@ -0,0 +1,112 @@
+package http
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+//
+// ========================
+//   ОТЧЁТНЫЕ ПЕРИОДЫ
+// ========================
+//
+
+// GET /api/periods?fundId= — периоды одного фонда
+func (s *Server) handlePeriods(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(405)
+		return
+	}
+
+	repo, ok := s.fundRepo(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := repo.ListPeriods(r.Context())
+	if err != nil {
+		writeJSON(w, 500, errorResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, 200, list)
+}
+
+// parsePeriodMonth читает {"month": "YYYY-MM", "fundId"}; без fundId —
+// фонд по умолчанию
+func parsePeriodMonth(r *http.Request) (time.Time, int64, string) {
+	var req struct {
+		Month  string `json:"month"`
+		FundID int64  `json:"fundId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return time.Time{}, 0, "invalid json"
+	}
+
+	month, err := time.Parse("2006-01", req.Month)
+	if err != nil {
+		return time.Time{}, 0, "invalid month, must be YYYY-MM"
+	}
+	return month, req.FundID, ""
+}
+
+// POST /api/periods/close
+func (s *Server) handleClosePeriod(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(405)
+		return
+	}
+
+	month, fundID, msg := parsePeriodMonth(r)
+	if msg != "" {
+		writeJSON(w, 400, errorResponse{Error: msg})
+		return
+	}
+
+	ctx := r.Context()
+	fundID, ok := s.resolveFund(ctx, w, fundID)
+	if !ok {
+		return
+	}
+
+	if err := s.repo.ClosePeriod(ctx, fundID, month, userIDFromContext(ctx)); err != nil {
+		writeJSON(w, 500, errorResponse{Error: err.Error()})
+		return
+	}
+
+	writeJSON(w, 200, map[string]any{"message": "closed", "month": month.Format("2006-01"), "fund_id": fundID})
+}
+
+// POST /api/periods/reopen
+func (s *Server) handleReopenPeriod(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(405)
+		return
+	}
+
+	month, fundID, msg := parsePeriodMonth(r)
+	if msg != "" {
+		writeJSON(w, 400, errorResponse{Error: msg})
+		return
+	}
+
+	ctx := r.Context()
+	fundID, ok := s.resolveFund(ctx, w, fundID)
+	if !ok {
+		return
+	}
+
+	err := s.repo.ReopenPeriod(ctx, fundID, month, userIDFromContext(ctx))
+	if errors.Is(err, sql.ErrNoRows) {
+		writeJSON(w, 404, errorResponse{Error: "period is not closed"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, 500, errorResponse{Error: err.Error()})
+		return
+	}
+
+	writeJSON(w, 200, map[string]any{"message": "reopened", "month": month.Format("2006-01"), "fund_id": fundID})
+}
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"invest/internal/calc"
 	"invest/internal/config"
 	"invest/internal/repository"
 	"net/http"
@@ -12,13 +13,22 @@ type Server struct {
 	repo          *repository.Repository
 	jwtSecret     []byte
 	secretRegCode string
+
+	// соглашение о днях по умолчанию для pro-rata расчётов
+	dayCount calc.DayCount
 }
 
 func NewServer(repo *repository.Repository, cfg *config.Config) *Server {
+	dayCount := calc.DayCount(cfg.DayCount)
+	if !dayCount.Valid() {
+		dayCount = calc.DayCountActual
+	}
+
 	return &Server{
 		repo:          repo,
 		jwtSecret:     []byte(cfg.JWTSecret),
 		secretRegCode: cfg.SecretRegCode,
+		dayCount:      dayCount,
 	}
 }
 
@@ -39,8 +49,24 @@ func (s *Server) Routes() http.Handler {
 	// ============================
 	//
 	mux.HandleFunc("/api/investors", s.withAuth(s.handleInvestors))
+	mux.HandleFunc("/api/investors/summary", s.withAuth(s.handleInvestorsSummary))
 	mux.HandleFunc("/api/investors/", s.withAuth(s.handleInvestorByID))
 
+	//
+	// ============================
+	//     FUNDS (protected)
+	// ============================
+	//
+	mux.HandleFunc("/api/funds", s.withAuth(s.handleFunds))
+	mux.HandleFunc("/api/funds/", s.withAuth(s.handleFundByID))
+
+	//
+	// ============================
+	//     DASHBOARD (protected)
+	// ============================
+	//
+	mux.HandleFunc("/api/dashboard", s.withAuth(s.handleDashboard))
+
 	//
 	// ============================
 	//     PAYOUTS (protected)
@@ -51,6 +77,64 @@ func (s *Server) Routes() http.Handler {
 
 	// Затем общий обработчик выплат
 	mux.HandleFunc("/api/payouts", s.withAuth(s.handlePayouts))
+	mux.HandleFunc("/api/payouts/", s.withAuth(s.handlePayoutByID))
+
+	//
+	// ============================
+	//     DISTRIBUTIONS (protected)
+	// ============================
+	//
+	mux.HandleFunc("/api/distributions", s.withAuth(s.handleDistributions))
+	mux.HandleFunc("/api/distributions/", s.withAuth(s.handleDistributionByID))
+
+	//
+	// ============================
+	//     SCHEDULES (protected)
+	// ============================
+	//
+	mux.HandleFunc("/api/schedules", s.withAuth(s.handleSchedules))
+	mux.HandleFunc("/api/schedules/upcoming", s.withAuth(s.handleUpcomingSchedules))
+	mux.HandleFunc("/api/schedules/", s.withAuth(s.handleScheduleByID))
+
+	//
+	// ============================
+	//     AGREEMENTS (protected)
+	// ============================
+	//
+	mux.HandleFunc("/api/agreements", s.withAuth(s.handleAgreements))
+	mux.HandleFunc("/api/agreements/", s.withAuth(s.handleAgreementByID))
+
+	//
+	// ============================
+	//     FEES (protected)
+	// ============================
+	//
+	mux.HandleFunc("/api/fees/charge", s.withAuth(s.handleChargeFees))
+
+	//
+	// ============================
+	//     TAX (protected)
+	// ============================
+	//
+	mux.HandleFunc("/api/tax", s.withAuth(s.handleAnnualTax))
+
+	//
+	// ============================
+	//     PERIODS (close/reopen — admin only)
+	// ============================
+	//
+	mux.HandleFunc("/api/periods", s.withAuth(s.handlePeriods))
+	mux.HandleFunc("/api/periods/close", s.withAdmin(s.handleClosePeriod))
+	mux.HandleFunc("/api/periods/reopen", s.withAdmin(s.handleReopenPeriod))
+
+	//
+	// ============================
+	//     UNITS / NAV (protected)
+	// ============================
+	//
+	mux.HandleFunc("/api/nav", s.withAuth(s.handleNAV))
+	mux.HandleFunc("/api/nav/seed", s.withAdmin(s.handleSeedUnits))
+	mux.HandleFunc("/api/units", s.withAuth(s.handleUnits))
 
 	//
 	// ============================
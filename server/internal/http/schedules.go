@@ -0,0 +1,270 @@
+package http
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"invest/internal/calc"
+	"invest/internal/models"
+	"invest/internal/repository"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultUpcomingDays = 30
+	maxUpcomingDays     = 366
+)
+
+//
+// ========================
+//       SCHEDULES
+// ========================
+//
+
+type scheduleRequest struct {
+	InvestorID int64           `json:"investorId"`
+	FundID     int64           `json:"fundId"` // 0 — фонд по умолчанию
+	Type       string          `json:"type"`
+	Rule       string          `json:"rule"`
+	Amount     *models.Decimal `json:"amount"`
+	DayOfMonth int             `json:"dayOfMonth"`
+	StartDate  string          `json:"startDate"`
+	EndDate    string          `json:"endDate"`
+}
+
+func (req scheduleRequest) toSchedule() (models.Schedule, string) {
+	sc := models.Schedule{
+		InvestorID: req.InvestorID,
+		FundID:     req.FundID,
+		Type:       models.PayoutType(req.Type),
+		Rule:       models.ScheduleRule(req.Rule),
+		DayOfMonth: req.DayOfMonth,
+	}
+
+	switch sc.Type {
+	case models.PayoutTopup, models.PayoutProfitWithdrawal, models.PayoutCapitalWithdrawal:
+	default:
+		return sc, "type must be topup, profit_withdrawal or capital_withdrawal"
+	}
+
+	if sc.Rule == "" {
+		sc.Rule = models.ScheduleFixed
+	}
+	switch sc.Rule {
+	case models.ScheduleFixed:
+		if req.Amount == nil || req.Amount.Sign() == 0 {
+			return sc, "amount is required for rule=fixed"
+		}
+		amount := req.Amount.Abs()
+		sc.Amount = &amount
+	case models.ScheduleNetProfit:
+		if sc.Type != models.PayoutProfitWithdrawal {
+			return sc, "rule=net_profit is only for profit_withdrawal"
+		}
+	default:
+		return sc, "rule must be fixed or net_profit"
+	}
+
+	if sc.DayOfMonth < 1 || sc.DayOfMonth > 31 {
+		return sc, "dayOfMonth must be between 1 and 31"
+	}
+
+	start, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return sc, "invalid startDate, must be YYYY-MM-DD"
+	}
+	sc.StartDate = start
+
+	if req.EndDate != "" {
+		end, err := time.Parse("2006-01-02", req.EndDate)
+		if err != nil {
+			return sc, "invalid endDate, must be YYYY-MM-DD"
+		}
+		if end.Before(start) {
+			return sc, "endDate must not be before startDate"
+		}
+		sc.EndDate = &end
+	}
+
+	return sc, ""
+}
+
+func (s *Server) handleSchedules(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	switch r.Method {
+
+	// ?fundId= — расписания одного фонда
+	case http.MethodGet:
+		repo, ok := s.fundRepo(w, r)
+		if !ok {
+			return
+		}
+
+		list, err := repo.ListSchedules(ctx, false)
+		if err != nil {
+			writeJSON(w, 500, errorResponse{Error: err.Error()})
+			return
+		}
+		writeJSON(w, 200, list)
+
+	case http.MethodPost:
+		var req scheduleRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, 400, errorResponse{Error: "invalid json"})
+			return
+		}
+
+		sc, msg := req.toSchedule()
+		if msg != "" {
+			writeJSON(w, 400, errorResponse{Error: msg})
+			return
+		}
+
+		if _, err := s.repo.GetInvestorByID(ctx, sc.InvestorID); errors.Is(err, sql.ErrNoRows) {
+			writeJSON(w, 404, errorResponse{Error: "investor not found"})
+			return
+		} else if err != nil {
+			writeJSON(w, 500, errorResponse{Error: err.Error()})
+			return
+		}
+
+		sc.CreatedBy = nullableUserID(ctx)
+		err := s.repo.CreateSchedule(ctx, &sc)
+		if errors.Is(err, sql.ErrNoRows) {
+			writeJSON(w, 404, errorResponse{Error: "fund not found"})
+			return
+		}
+		if errors.Is(err, repository.ErrNoPosition) {
+			writeJSON(w, 422, errorResponse{Error: err.Error()})
+			return
+		}
+		if err != nil {
+			writeJSON(w, 500, errorResponse{Error: err.Error()})
+			return
+		}
+
+		writeJSON(w, 201, sc)
+
+	default:
+		w.WriteHeader(405)
+	}
+}
+
+// GET /api/schedules/upcoming?days=30&fundId= — операции по расписаниям на ближайшие дни
+func (s *Server) handleUpcomingSchedules(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(405)
+		return
+	}
+
+	ctx := r.Context()
+
+	repo, ok := s.fundRepo(w, r)
+	if !ok {
+		return
+	}
+
+	days := defaultUpcomingDays
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxUpcomingDays {
+			writeJSON(w, 400, errorResponse{Error: "days must be between 1 and 366"})
+			return
+		}
+		days = n
+	}
+
+	schedules, err := repo.ListSchedules(ctx, true)
+	if err != nil {
+		writeJSON(w, 500, errorResponse{Error: err.Error()})
+		return
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	to := today.AddDate(0, 0, days)
+
+	out := []models.ScheduledOperation{}
+	for _, sc := range schedules {
+		from := today
+		// уже проведённые даты не показываем
+		if sc.LastRunDate != nil && !sc.LastRunDate.Before(from) {
+			from = sc.LastRunDate.AddDate(0, 0, 1)
+		}
+
+		for _, due := range calc.ScheduleDueDates(sc, from, to) {
+			out = append(out, models.ScheduledOperation{
+				ScheduleID: sc.ID,
+				InvestorID: sc.InvestorID,
+				FundID:     sc.FundID,
+				Type:       sc.Type,
+				Rule:       sc.Rule,
+				Amount:     sc.Amount,
+				DueDate:    due,
+			})
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].DueDate.Before(out[j].DueDate)
+	})
+
+	writeJSON(w, 200, out)
+}
+
+// /api/schedules/{id}: GET — расписание и история проведений, DELETE — остановить
+func (s *Server) handleScheduleByID(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/schedules/")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		writeJSON(w, 400, errorResponse{Error: "invalid schedule id"})
+		return
+	}
+
+	switch r.Method {
+
+	case http.MethodGet:
+		sc, err := s.repo.GetSchedule(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			writeJSON(w, 404, errorResponse{Error: "schedule not found"})
+			return
+		}
+		if err != nil {
+			writeJSON(w, 500, errorResponse{Error: err.Error()})
+			return
+		}
+
+		runs, err := s.repo.ListScheduleRuns(ctx, id)
+		if err != nil {
+			writeJSON(w, 500, errorResponse{Error: err.Error()})
+			return
+		}
+
+		writeJSON(w, 200, map[string]any{
+			"schedule": sc,
+			"runs":     runs,
+		})
+
+	case http.MethodDelete:
+		err := s.repo.DeactivateSchedule(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			writeJSON(w, 404, errorResponse{Error: "schedule not found"})
+			return
+		}
+		if err != nil {
+			writeJSON(w, 500, errorResponse{Error: err.Error()})
+			return
+		}
+
+		writeJSON(w, 200, map[string]string{"message": "deactivated"})
+
+	default:
+		w.WriteHeader(405)
+	}
+}
@@ -0,0 +1,257 @@
+package http
+
+import (
+	"database/sql"
+	"errors"
+	"invest/internal/calc"
+	"invest/internal/models"
+	"net/http"
+	"time"
+)
+
+//
+// ========================
+//      SUMMARY (расчёты)
+// ========================
+//
+
+// GET /api/investors/summary?asOf=YYYY-MM-DD — показатели по всем инвесторам;
+// с asOf учитываются только операции с period_date не позже этой даты,
+// а сумма вложений и доля — действовавшие на эту дату
+func (s *Server) handleInvestorsSummary(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(405)
+		return
+	}
+
+	ctx := r.Context()
+
+	repo, ok := s.fundRepo(w, r)
+	if !ok {
+		return
+	}
+
+	asOf, err := parseAsOf(r)
+	if err != nil {
+		writeJSON(w, 400, errorResponse{Error: "invalid asOf, must be YYYY-MM-DD"})
+		return
+	}
+
+	var investors []models.Investor
+	var payouts []models.Payout
+	if asOf.IsZero() {
+		investors, err = repo.ListInvestors(ctx)
+	} else {
+		investors, err = repo.ListInvestorsAsOf(ctx, asOf)
+	}
+	if err != nil {
+		writeJSON(w, 500, errorResponse{Error: err.Error()})
+		return
+	}
+
+	if asOf.IsZero() {
+		payouts, err = repo.GetPayouts(ctx)
+	} else {
+		payouts, err = repo.GetPayoutsAsOf(ctx, asOf)
+	}
+	if err != nil {
+		writeJSON(w, 500, errorResponse{Error: err.Error()})
+		return
+	}
+
+	list := calc.SummarizeAll(investors, payouts)
+	if !asOf.IsZero() {
+		for i := range list {
+			list[i].AsOf = &asOf
+		}
+	}
+
+	writeJSON(w, 200, list)
+}
+
+// GET /api/investors/{id}/summary?asOf=YYYY-MM-DD — показатели одного инвестора
+// (с asOf — как в handleInvestorsSummary)
+func (s *Server) handleInvestorSummary(w http.ResponseWriter, r *http.Request, id int64) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(405)
+		return
+	}
+
+	ctx := r.Context()
+
+	repo, ok := s.fundRepo(w, r)
+	if !ok {
+		return
+	}
+
+	asOf, err := parseAsOf(r)
+	if err != nil {
+		writeJSON(w, 400, errorResponse{Error: "invalid asOf, must be YYYY-MM-DD"})
+		return
+	}
+
+	var inv *models.Investor
+	if asOf.IsZero() {
+		inv, err = repo.GetInvestorByID(ctx, id)
+	} else {
+		inv, err = repo.GetInvestorByIDAsOf(ctx, id, asOf)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		writeJSON(w, 404, errorResponse{Error: "investor not found"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, 500, errorResponse{Error: err.Error()})
+		return
+	}
+
+	var payouts []models.Payout
+	if asOf.IsZero() {
+		payouts, err = repo.GetPayoutsByInvestor(ctx, id)
+	} else {
+		payouts, err = repo.GetPayoutsByInvestorAsOf(ctx, id, asOf)
+	}
+	if err != nil {
+		writeJSON(w, 500, errorResponse{Error: err.Error()})
+		return
+	}
+
+	summary := calc.Summarize(*inv, payouts)
+	if !asOf.IsZero() {
+		summary.AsOf = &asOf
+	}
+
+	writeJSON(w, 200, summary)
+}
+
+// GET /api/investors/{id}/performance?from=YYYY-MM-DD&to=YYYY-MM-DD
+// XIRR, TWR и простой ROI; без from — с даты создания инвестора, без to — по сегодня
+func (s *Server) handleInvestorPerformance(w http.ResponseWriter, r *http.Request, id int64) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(405)
+		return
+	}
+
+	ctx := r.Context()
+
+	repo, ok := s.fundRepo(w, r)
+	if !ok {
+		return
+	}
+
+	from, err := parseDateParam(r, "from")
+	if err != nil {
+		writeJSON(w, 400, errorResponse{Error: "invalid from, must be YYYY-MM-DD"})
+		return
+	}
+	to, err := parseDateParam(r, "to")
+	if err != nil {
+		writeJSON(w, 400, errorResponse{Error: "invalid to, must be YYYY-MM-DD"})
+		return
+	}
+	if to.IsZero() {
+		to = time.Now().UTC().Truncate(24 * time.Hour)
+	}
+	if !from.IsZero() && to.Before(from) {
+		writeJSON(w, 400, errorResponse{Error: "to must not be before from"})
+		return
+	}
+
+	inv, err := repo.GetInvestorByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeJSON(w, 404, errorResponse{Error: "investor not found"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, 500, errorResponse{Error: err.Error()})
+		return
+	}
+
+	payouts, err := repo.GetPayoutsByInvestor(ctx, id)
+	if err != nil {
+		writeJSON(w, 500, errorResponse{Error: err.Error()})
+		return
+	}
+
+	writeJSON(w, 200, calc.CalcPerformance(*inv, payouts, from, to))
+}
+
+// GET /api/investors/{id}/capital-base?from=YYYY-MM-DD&to=YYYY-MM-DD&dayCount=act/act
+// Средний по дням капитал за период с расшифровкой по отрезкам; по умолчанию — текущий месяц
+func (s *Server) handleInvestorCapitalBase(w http.ResponseWriter, r *http.Request, id int64) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(405)
+		return
+	}
+
+	ctx := r.Context()
+
+	repo, ok := s.fundRepo(w, r)
+	if !ok {
+		return
+	}
+
+	from, err := parseDateParam(r, "from")
+	if err != nil {
+		writeJSON(w, 400, errorResponse{Error: "invalid from, must be YYYY-MM-DD"})
+		return
+	}
+	to, err := parseDateParam(r, "to")
+	if err != nil {
+		writeJSON(w, 400, errorResponse{Error: "invalid to, must be YYYY-MM-DD"})
+		return
+	}
+
+	monthStart, monthEnd := calc.MonthBounds(time.Now().UTC())
+	if from.IsZero() {
+		from = monthStart
+	}
+	if to.IsZero() {
+		to = monthEnd
+	}
+	if to.Before(from) {
+		writeJSON(w, 400, errorResponse{Error: "to must not be before from"})
+		return
+	}
+
+	dayCount := s.dayCount
+	if v := r.URL.Query().Get("dayCount"); v != "" {
+		dayCount = calc.DayCount(v)
+		if !dayCount.Valid() {
+			writeJSON(w, 400, errorResponse{Error: "dayCount must be act/act or 30/360"})
+			return
+		}
+	}
+
+	inv, err := repo.GetInvestorByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeJSON(w, 404, errorResponse{Error: "investor not found"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, 500, errorResponse{Error: err.Error()})
+		return
+	}
+
+	payouts, err := repo.GetPayoutsByInvestor(ctx, id)
+	if err != nil {
+		writeJSON(w, 500, errorResponse{Error: err.Error()})
+		return
+	}
+
+	writeJSON(w, 200, calc.AverageCapital(*inv, payouts, from, to, dayCount))
+}
+
+// parseAsOf — необязательная дата баланса ?asOf=YYYY-MM-DD
+func parseAsOf(r *http.Request) (time.Time, error) {
+	return parseDateParam(r, "asOf")
+}
+
+// parseDateParam читает необязательный query-параметр YYYY-MM-DD (нулевое время, если нет)
+func parseDateParam(r *http.Request, name string) (time.Time, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse("2006-01-02", v)
+}
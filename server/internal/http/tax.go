@@ -0,0 +1,53 @@
+package http
+
+import (
+	"invest/internal/calc"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+//
+// ========================
+//          TAX
+// ========================
+//
+
+// GET /api/tax?year=YYYY — удержанный налог по инвесторам за год (по умолчанию текущий)
+func (s *Server) handleAnnualTax(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(405)
+		return
+	}
+
+	ctx := r.Context()
+
+	repo, ok := s.fundRepo(w, r)
+	if !ok {
+		return
+	}
+
+	year := time.Now().UTC().Year()
+	if v := r.URL.Query().Get("year"); v != "" {
+		y, err := strconv.Atoi(v)
+		if err != nil || y < 1900 || y > 9999 {
+			writeJSON(w, 400, errorResponse{Error: "invalid year"})
+			return
+		}
+		year = y
+	}
+
+	investors, err := repo.ListInvestors(ctx)
+	if err != nil {
+		writeJSON(w, 500, errorResponse{Error: err.Error()})
+		return
+	}
+
+	payouts, err := repo.GetPayouts(ctx)
+	if err != nil {
+		writeJSON(w, 500, errorResponse{Error: err.Error()})
+		return
+	}
+
+	writeJSON(w, 200, calc.AnnualTax(investors, payouts, year))
+}
@@ -0,0 +1,227 @@
+package http
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"invest/internal/calc"
+	"invest/internal/models"
+	"invest/internal/repository"
+	"net/http"
+	"time"
+)
+
+//
+// ========================
+//   ПАЕВОЙ УЧЁТ (NAV)
+// ========================
+//
+
+// GET /api/nav?fundId= — история стоимости пая (без fundId — всех фондов)
+// POST /api/nav — результат месяца фонда: {"date", "resultPercent"} или
+// {"date", "totalAssets"}, плюс необязательный "fundId"
+func (s *Server) handleNAV(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	switch r.Method {
+
+	case http.MethodGet:
+		repo, ok := s.fundRepo(w, r)
+		if !ok {
+			return
+		}
+
+		list, err := repo.ListNAV(ctx)
+		if err != nil {
+			writeJSON(w, 500, errorResponse{Error: err.Error()})
+			return
+		}
+		writeJSON(w, 200, list)
+
+	case http.MethodPost:
+		if !s.repo.UnitMode() {
+			writeJSON(w, 409, errorResponse{Error: "fund is not in units mode (FUND_MODE=units)"})
+			return
+		}
+
+		var req struct {
+			Date          string          `json:"date"`
+			FundID        int64           `json:"fundId"`
+			ResultPercent *models.Decimal `json:"resultPercent"`
+			TotalAssets   *models.Decimal `json:"totalAssets"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, 400, errorResponse{Error: "invalid json"})
+			return
+		}
+
+		date, err := time.Parse("2006-01-02", req.Date)
+		if err != nil {
+			writeJSON(w, 400, errorResponse{Error: "invalid date, must be YYYY-MM-DD"})
+			return
+		}
+
+		if (req.ResultPercent == nil) == (req.TotalAssets == nil) {
+			writeJSON(w, 400, errorResponse{Error: "exactly one of resultPercent or totalAssets required"})
+			return
+		}
+		if req.TotalAssets != nil && req.TotalAssets.Sign() <= 0 {
+			writeJSON(w, 400, errorResponse{Error: "totalAssets must be > 0"})
+			return
+		}
+
+		fundID, ok := s.resolveFund(ctx, w, req.FundID)
+		if !ok {
+			return
+		}
+
+		n := models.FundNAV{
+			FundID:    fundID,
+			NAVDate:   date,
+			CreatedBy: nullableUserID(ctx),
+		}
+
+		err = s.repo.RecordNAV(ctx, &n, req.ResultPercent, req.TotalAssets)
+		if errors.Is(err, repository.ErrPeriodClosed) {
+			writeJSON(w, 409, errorResponse{Error: err.Error()})
+			return
+		}
+		if errors.Is(err, repository.ErrNoUnits) ||
+			errors.Is(err, repository.ErrNAVNotPositive) {
+			writeJSON(w, 422, errorResponse{Error: err.Error()})
+			return
+		}
+		if err != nil {
+			writeJSON(w, 500, errorResponse{Error: err.Error()})
+			return
+		}
+
+		writeJSON(w, 201, n)
+
+	default:
+		w.WriteHeader(405)
+	}
+}
+
+// POST /api/nav/seed {"date", "fundId"} — перевести текущий капитал позиций
+// фонда в паи тем инвесторам, у кого паёв фонда ещё нет (переход на паевой учёт)
+func (s *Server) handleSeedUnits(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(405)
+		return
+	}
+	if !s.repo.UnitMode() {
+		writeJSON(w, 409, errorResponse{Error: "fund is not in units mode (FUND_MODE=units)"})
+		return
+	}
+
+	ctx := r.Context()
+
+	var req struct {
+		Date   string `json:"date"`
+		FundID int64  `json:"fundId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, 400, errorResponse{Error: "invalid json"})
+		return
+	}
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		writeJSON(w, 400, errorResponse{Error: "invalid date, must be YYYY-MM-DD"})
+		return
+	}
+
+	fundID, ok := s.resolveFund(ctx, w, req.FundID)
+	if !ok {
+		return
+	}
+	repo := s.repo.InFund(fundID)
+
+	investors, err := repo.ListInvestors(ctx)
+	if err != nil {
+		writeJSON(w, 500, errorResponse{Error: err.Error()})
+		return
+	}
+	payouts, err := repo.GetPayouts(ctx)
+	if err != nil {
+		writeJSON(w, 500, errorResponse{Error: err.Error()})
+		return
+	}
+
+	capitals := make(map[int64]models.Decimal, len(investors))
+	for _, sum := range calc.SummarizeAll(investors, payouts) {
+		capitals[sum.InvestorID] = sum.CapitalNow
+	}
+
+	seeded, err := s.repo.SeedUnits(ctx, fundID, date, capitals)
+	if err != nil {
+		writeJSON(w, 500, errorResponse{Error: err.Error()})
+		return
+	}
+
+	writeJSON(w, 200, map[string]any{"seeded": seeded})
+}
+
+// GET /api/units?asOf=YYYY-MM-DD&fundId= — паи и стоимость по всем инвесторам
+// фонда (по умолчанию — сейчас и фонд по умолчанию)
+func (s *Server) handleUnits(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(405)
+		return
+	}
+
+	repo, ok := s.singleFundRepo(w, r)
+	if !ok {
+		return
+	}
+
+	asOf, err := parseAsOf(r)
+	if err != nil {
+		writeJSON(w, 400, errorResponse{Error: "invalid asOf, must be YYYY-MM-DD"})
+		return
+	}
+	if asOf.IsZero() {
+		asOf = time.Now().UTC()
+	}
+
+	list, err := repo.ListUnitHoldings(r.Context(), asOf)
+	if err != nil {
+		writeJSON(w, 500, errorResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, 200, list)
+}
+
+// GET /api/investors/{id}/units?asOf=YYYY-MM-DD&fundId= — паи инвестора
+// в фонде и история сделок
+func (s *Server) handleInvestorUnits(w http.ResponseWriter, r *http.Request, id int64) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(405)
+		return
+	}
+
+	repo, ok := s.singleFundRepo(w, r)
+	if !ok {
+		return
+	}
+
+	asOf, err := parseAsOf(r)
+	if err != nil {
+		writeJSON(w, 400, errorResponse{Error: "invalid asOf, must be YYYY-MM-DD"})
+		return
+	}
+	if asOf.IsZero() {
+		asOf = time.Now().UTC()
+	}
+
+	h, err := repo.GetUnitHolding(r.Context(), id, asOf)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeJSON(w, 404, errorResponse{Error: "investor not found"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, 500, errorResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, 200, h)
+}
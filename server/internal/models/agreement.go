@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// ========================
+//       AGREEMENT
+// ========================
+
+// Agreement — договор инвестирования. Снятие капитала проверяется по договору,
+// действующему на дату снятия: уведомление не позже чем за NoticeDays дней,
+// раньше LockupUntil — штраф PenaltyPercent от суммы снятия.
+type Agreement struct {
+	ID             int64      `json:"id"`
+	InvestorID     int64      `json:"investor_id"`
+	FundID         int64      `json:"fund_id"`
+	Number         string     `json:"number"`
+	StartDate      time.Time  `json:"start_date"`
+	EndDate        *time.Time `json:"end_date,omitempty"`     // nil — бессрочный
+	LockupUntil    *time.Time `json:"lockup_until,omitempty"` // nil — без lock-up
+	NoticeDays     int        `json:"notice_days"`
+	PenaltyPercent Decimal    `json:"penalty_percent"`
+	CreatedBy      *int64     `json:"created_by,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// InLockup — дата снятия раньше окончания lock-up (с LockupUntil уже без штрафа)
+func (a Agreement) InLockup(date time.Time) bool {
+	return a.LockupUntil != nil && date.Before(*a.LockupUntil)
+}
+
+// EarliestWithdrawal — первая дата снятия, допустимая при уведомлении noticeDate
+func (a Agreement) EarliestWithdrawal(noticeDate time.Time) time.Time {
+	return noticeDate.AddDate(0, 0, a.NoticeDays)
+}
@@ -0,0 +1,391 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ========================
+//        DECIMAL
+// ========================
+
+// Decimal — число с фиксированной точкой и двумя знаками после запятой.
+// Соответствует колонкам NUMERIC(18,2) и NUMERIC(5,2): суммы хранятся
+// в копейках, проценты — в сотых долях процента, без ошибок float64.
+type Decimal struct {
+	cents int64
+}
+
+const decimalScale = 100
+
+var errInvalidDecimal = errors.New("invalid decimal")
+
+// NewDecimal собирает число из целой части и сотых: NewDecimal(12, 50) == 12.50
+func NewDecimal(units, cents int64) Decimal {
+	if units < 0 {
+		cents = -cents
+	}
+	return Decimal{cents: units*decimalScale + cents}
+}
+
+// DecimalFromInt — целое число без дробной части
+func DecimalFromInt(v int64) Decimal {
+	return Decimal{cents: v * decimalScale}
+}
+
+// DecimalFromCents — число из количества сотых долей (копеек)
+func DecimalFromCents(c int64) Decimal {
+	return Decimal{cents: c}
+}
+
+// ParseDecimal разбирает строку вида "-1234.56".
+// Лишние знаки после запятой округляются до сотых (половина — от нуля).
+func ParseDecimal(s string) (Decimal, error) {
+	v, err := parseFixed(s, 2)
+	if err != nil {
+		return Decimal{}, err
+	}
+	return Decimal{cents: v}, nil
+}
+
+// parseFixed разбирает десятичную строку в целое с digits знаками после запятой
+func parseFixed(s string, digits int) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, errInvalidDecimal
+	}
+
+	scale := pow10(digits)
+
+	// экспоненциальная запись (1e+06) — через big.Rat, без потери точности
+	if strings.ContainsAny(s, "eE") {
+		rat, ok := new(big.Rat).SetString(s)
+		if !ok {
+			return 0, fmt.Errorf("%w: %q", errInvalidDecimal, s)
+		}
+		return fixedFromRat(rat, scale)
+	}
+
+	orig := s
+	neg := false
+	switch s[0] {
+	case '-':
+		neg = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	if intPart == "" && fracPart == "" {
+		return 0, errInvalidDecimal
+	}
+	if intPart == "" {
+		intPart = "0"
+	}
+
+	// только цифры: ParseInt сам принял бы второй знак ("-+5")
+	for _, ch := range intPart {
+		if ch < '0' || ch > '9' {
+			return 0, fmt.Errorf("%w: %q", errInvalidDecimal, orig)
+		}
+	}
+	units, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", errInvalidDecimal, orig)
+	}
+
+	var frac int64
+	roundUp := false
+	for i, ch := range fracPart {
+		if ch < '0' || ch > '9' {
+			return 0, fmt.Errorf("%w: %q", errInvalidDecimal, orig)
+		}
+		switch {
+		case i < digits:
+			frac = frac*10 + int64(ch-'0')
+		case i == digits:
+			roundUp = ch >= '5'
+		}
+	}
+	for i := len(fracPart); i < digits; i++ {
+		frac *= 10
+	}
+
+	if units > (1<<63-1)/scale-1 {
+		return 0, fmt.Errorf("%w: %q out of range", errInvalidDecimal, orig)
+	}
+
+	total := units*scale + frac
+	if roundUp {
+		total++
+	}
+	if neg {
+		total = -total
+	}
+	return total, nil
+}
+
+func fixedFromRat(r *big.Rat, scale int64) (int64, error) {
+	scaled := new(big.Rat).Mul(r, big.NewRat(scale, 1))
+	num := new(big.Int).Set(scaled.Num())
+	den := scaled.Denom()
+
+	q, m := new(big.Int).QuoRem(num, den, new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(m), big.NewInt(2)).Cmp(den) >= 0 {
+		if num.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	if !q.IsInt64() {
+		return 0, fmt.Errorf("%w: out of range", errInvalidDecimal)
+	}
+	return q.Int64(), nil
+}
+
+// formatFixed печатает целое v как число с digits знаками после запятой
+func formatFixed(v int64, digits int) string {
+	scale := pow10(digits)
+	sign := ""
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	return fmt.Sprintf("%s%d.%0*d", sign, v/scale, digits, v%scale)
+}
+
+func pow10(n int) int64 {
+	p := int64(1)
+	for i := 0; i < n; i++ {
+		p *= 10
+	}
+	return p
+}
+
+// MustDecimal — для констант в коде; паникует на неверной строке
+func MustDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// ----------------------------------------------------------
+// Арифметика
+// ----------------------------------------------------------
+
+func (d Decimal) Cents() int64 { return d.cents }
+
+func (d Decimal) Add(o Decimal) Decimal { return Decimal{cents: d.cents + o.cents} }
+func (d Decimal) Sub(o Decimal) Decimal { return Decimal{cents: d.cents - o.cents} }
+func (d Decimal) Neg() Decimal          { return Decimal{cents: -d.cents} }
+
+func (d Decimal) Abs() Decimal {
+	if d.cents < 0 {
+		return d.Neg()
+	}
+	return d
+}
+
+func (d Decimal) Sign() int {
+	switch {
+	case d.cents < 0:
+		return -1
+	case d.cents > 0:
+		return 1
+	}
+	return 0
+}
+
+func (d Decimal) IsZero() bool { return d.cents == 0 }
+
+// Cmp возвращает -1, 0 или 1
+func (d Decimal) Cmp(o Decimal) int {
+	switch {
+	case d.cents < o.cents:
+		return -1
+	case d.cents > o.cents:
+		return 1
+	}
+	return 0
+}
+
+func MaxDecimal(a, b Decimal) Decimal {
+	if a.cents >= b.cents {
+		return a
+	}
+	return b
+}
+
+func MinDecimal(a, b Decimal) Decimal {
+	if a.cents <= b.cents {
+		return a
+	}
+	return b
+}
+
+// MulPercent — d × p / 100 с округлением до сотых.
+// Пример: 100000.00 × 3.5% = 3500.00
+func (d Decimal) MulPercent(p Decimal) Decimal {
+	return Decimal{cents: mulDivRound(d.cents, p.cents, 100*decimalScale)}
+}
+
+// MulRatio — d × num / den с округлением до сотых (пропорции, доли пула)
+func (d Decimal) MulRatio(num, den Decimal) Decimal {
+	if den.cents == 0 {
+		return Decimal{}
+	}
+	return Decimal{cents: mulDivRound(d.cents, num.cents, den.cents)}
+}
+
+// mulDivRound считает a*b/c без переполнения, округляя половину от нуля
+func mulDivRound(a, b, c int64) int64 {
+	num := new(big.Int).Mul(big.NewInt(a), big.NewInt(b))
+	den := big.NewInt(c)
+
+	q, m := new(big.Int).QuoRem(num, den, new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(m), big.NewInt(2)).Cmp(new(big.Int).Abs(den)) >= 0 {
+		if (num.Sign() < 0) != (den.Sign() < 0) {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q.Int64()
+}
+
+// Allocate делит d на части пропорционально весам с точностью до копейки
+// методом наибольшего остатка: сумма частей в точности равна d.
+// Отрицательные веса считаются нулевыми; при равных остатках копейка
+// достаётся части с меньшим индексом. Если все веса нулевые — все части нулевые.
+func (d Decimal) Allocate(weights []Decimal) []Decimal {
+	out := make([]Decimal, len(weights))
+
+	total := new(big.Int)
+	for _, w := range weights {
+		if w.cents > 0 {
+			total.Add(total, big.NewInt(w.cents))
+		}
+	}
+	if total.Sign() == 0 {
+		return out
+	}
+
+	rems := make([]*big.Int, len(weights))
+	allocated := int64(0)
+	for i, w := range weights {
+		rems[i] = new(big.Int)
+		if w.cents <= 0 {
+			continue
+		}
+		num := new(big.Int).Mul(big.NewInt(d.cents), big.NewInt(w.cents))
+		q, m := new(big.Int).QuoRem(num, total, rems[i])
+		// QuoRem усекает к нулю; для отрицательной суммы приводим к floor
+		if m.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+			m.Add(m, total)
+		}
+		out[i] = Decimal{cents: q.Int64()}
+		allocated += q.Int64()
+	}
+
+	order := make([]int, 0, len(weights))
+	for i, w := range weights {
+		if w.cents > 0 {
+			order = append(order, i)
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return rems[order[a]].Cmp(rems[order[b]]) > 0
+	})
+
+	for k := int64(0); k < d.cents-allocated; k++ {
+		i := order[k%int64(len(order))]
+		out[i].cents++
+	}
+
+	return out
+}
+
+// RoundUnits округляет до целых (как Math.round на клиенте для сумм выплат)
+func (d Decimal) RoundUnits() Decimal {
+	return Decimal{cents: mulDivRound(d.cents, 1, decimalScale) * decimalScale}
+}
+
+// Float64 — только для отображения и аналитики, не для сумм
+func (d Decimal) Float64() float64 {
+	return float64(d.cents) / decimalScale
+}
+
+func (d Decimal) String() string {
+	return formatFixed(d.cents, 2)
+}
+
+// ----------------------------------------------------------
+// JSON
+// ----------------------------------------------------------
+
+// MarshalJSON пишет число (не строку), чтобы клиентский Number(...) работал как раньше
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON принимает и число, и строку: 1500, 1500.5, "1500.50"
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	s := strings.TrimSpace(string(b))
+	if s == "null" {
+		return nil
+	}
+	s = strings.Trim(s, `"`)
+	s = strings.ReplaceAll(s, ",", ".")
+
+	v, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// ----------------------------------------------------------
+// SQL
+// ----------------------------------------------------------
+
+// Scan читает NUMERIC из PostgreSQL (lib/pq отдаёт его как []byte)
+func (d *Decimal) Scan(src any) error {
+	v, err := scanFixed(src, 2)
+	if err != nil {
+		return err
+	}
+	*d = Decimal{cents: v}
+	return nil
+}
+
+func scanFixed(src any, digits int) (int64, error) {
+	switch v := src.(type) {
+	case []byte:
+		return parseFixed(string(v), digits)
+	case string:
+		return parseFixed(v, digits)
+	case int64:
+		return v * pow10(digits), nil
+	case float64:
+		return parseFixed(strconv.FormatFloat(v, 'f', -1, 64), digits)
+	case nil:
+		return 0, errors.New("decimal: cannot scan NULL, use a pointer")
+	}
+	return 0, fmt.Errorf("decimal: unsupported type %T", src)
+}
+
+// Value отдаёт строку — PostgreSQL сам приведёт её к NUMERIC без потерь
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
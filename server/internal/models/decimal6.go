@@ -0,0 +1,121 @@
+package models
+
+import (
+	"database/sql/driver"
+	"strings"
+)
+
+// ========================
+//        DECIMAL6
+// ========================
+
+// Decimal6 — число с шестью знаками после запятой: количество паёв
+// и стоимость пая (NAV). Колонки NUMERIC(24,6) / NUMERIC(20,6).
+type Decimal6 struct {
+	micros int64
+}
+
+const decimal6Scale = 1_000_000
+
+func Decimal6FromInt(v int64) Decimal6 {
+	return Decimal6{micros: v * decimal6Scale}
+}
+
+func ParseDecimal6(s string) (Decimal6, error) {
+	v, err := parseFixed(s, 6)
+	if err != nil {
+		return Decimal6{}, err
+	}
+	return Decimal6{micros: v}, nil
+}
+
+func (d Decimal6) Add(o Decimal6) Decimal6 { return Decimal6{micros: d.micros + o.micros} }
+func (d Decimal6) Sub(o Decimal6) Decimal6 { return Decimal6{micros: d.micros - o.micros} }
+func (d Decimal6) Neg() Decimal6           { return Decimal6{micros: -d.micros} }
+func (d Decimal6) IsZero() bool            { return d.micros == 0 }
+
+func (d Decimal6) Sign() int {
+	switch {
+	case d.micros < 0:
+		return -1
+	case d.micros > 0:
+		return 1
+	}
+	return 0
+}
+
+func (d Decimal6) Float64() float64 {
+	return float64(d.micros) / decimal6Scale
+}
+
+func (d Decimal6) String() string {
+	return formatFixed(d.micros, 6)
+}
+
+// ----------------------------------------------------------
+// Паи и стоимость пая
+// ----------------------------------------------------------
+
+// UnitsForAmount — сколько паёв покупается (или погашается) на сумму по цене nav
+func UnitsForAmount(amount Decimal, nav Decimal6) Decimal6 {
+	if nav.micros == 0 {
+		return Decimal6{}
+	}
+	// (коп / 100) / (nav / 1e6) × 1e6 = коп × 1e10 / nav
+	return Decimal6{micros: mulDivRound(amount.cents, 10_000_000_000, nav.micros)}
+}
+
+// ValueAt — стоимость паёв по цене nav, до копеек
+func (d Decimal6) ValueAt(nav Decimal6) Decimal {
+	return Decimal{cents: mulDivRound(d.micros, nav.micros, 10_000_000_000)}
+}
+
+// GrowPercent — nav × (1 + p / 100): движение стоимости пая за месяц
+func (d Decimal6) GrowPercent(p Decimal) Decimal6 {
+	return Decimal6{micros: mulDivRound(d.micros, 100*decimalScale+p.cents, 100*decimalScale)}
+}
+
+// NAVFromAssets — стоимость пая = активы / количество паёв
+func NAVFromAssets(assets Decimal, units Decimal6) Decimal6 {
+	if units.micros == 0 {
+		return Decimal6{}
+	}
+	return Decimal6{micros: mulDivRound(assets.cents, 10_000_000_000, units.micros)}
+}
+
+// ----------------------------------------------------------
+// JSON / SQL
+// ----------------------------------------------------------
+
+func (d Decimal6) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Decimal6) UnmarshalJSON(b []byte) error {
+	s := strings.TrimSpace(string(b))
+	if s == "null" {
+		return nil
+	}
+	s = strings.Trim(s, `"`)
+	s = strings.ReplaceAll(s, ",", ".")
+
+	v, err := ParseDecimal6(s)
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+func (d *Decimal6) Scan(src any) error {
+	v, err := scanFixed(src, 6)
+	if err != nil {
+		return err
+	}
+	*d = Decimal6{micros: v}
+	return nil
+}
+
+func (d Decimal6) Value() (driver.Value, error) {
+	return d.String(), nil
+}
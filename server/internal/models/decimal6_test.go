@@ -0,0 +1,113 @@
+package models
+
+import "testing"
+
+func TestParseDecimal6(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "1", want: "1.000000"},
+		{in: "0.1234565", want: "0.123457"},
+		{in: "-0.0000005", want: "-0.000001"},
+		{in: "12.5", want: "12.500000"},
+		{in: "-+1", wantErr: true},
+		{in: "1..2", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDecimal6(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseDecimal6(%q) = %s, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseDecimal6(%q): %v", tt.in, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("ParseDecimal6(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func mustDecimal6(s string) Decimal6 {
+	d, err := ParseDecimal6(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func TestUnitsForAmount(t *testing.T) {
+	tests := []struct {
+		amount, nav, want string
+	}{
+		{"1000.00", "1", "1000.000000"},
+		{"1000.00", "3", "333.333333"},
+		{"2000.00", "3", "666.666667"},
+		{"-500.00", "2", "-250.000000"},
+		{"100.00", "0", "0.000000"}, // цены нет — паёв нет
+	}
+
+	for _, tt := range tests {
+		got := UnitsForAmount(MustDecimal(tt.amount), mustDecimal6(tt.nav))
+		if got.String() != tt.want {
+			t.Errorf("UnitsForAmount(%s, %s) = %s, want %s", tt.amount, tt.nav, got, tt.want)
+		}
+	}
+}
+
+func TestDecimal6ValueAt(t *testing.T) {
+	tests := []struct {
+		units, nav, want string
+	}{
+		{"333.333333", "3", "1000.00"},
+		{"1000", "1.234567", "1234.57"},
+		{"0.000001", "1", "0.00"},
+	}
+
+	for _, tt := range tests {
+		got := mustDecimal6(tt.units).ValueAt(mustDecimal6(tt.nav))
+		if got.String() != tt.want {
+			t.Errorf("%s × %s = %s, want %s", tt.units, tt.nav, got, tt.want)
+		}
+	}
+}
+
+func TestNAVFromAssets(t *testing.T) {
+	tests := []struct {
+		assets, units, want string
+	}{
+		{"1000.00", "1000", "1.000000"},
+		{"1000.00", "3", "333.333333"},
+		{"1000.00", "0", "0.000000"},
+	}
+
+	for _, tt := range tests {
+		got := NAVFromAssets(MustDecimal(tt.assets), mustDecimal6(tt.units))
+		if got.String() != tt.want {
+			t.Errorf("NAVFromAssets(%s, %s) = %s, want %s", tt.assets, tt.units, got, tt.want)
+		}
+	}
+}
+
+func TestDecimal6GrowPercent(t *testing.T) {
+	tests := []struct {
+		nav, percent, want string
+	}{
+		{"1", "3.5", "1.035000"},
+		{"1.035", "-10", "0.931500"},
+		{"2", "0", "2.000000"},
+	}
+
+	for _, tt := range tests {
+		got := mustDecimal6(tt.nav).GrowPercent(MustDecimal(tt.percent))
+		if got.String() != tt.want {
+			t.Errorf("%s × (1 + %s%%) = %s, want %s", tt.nav, tt.percent, got, tt.want)
+		}
+	}
+}
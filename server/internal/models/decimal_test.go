@@ -0,0 +1,211 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "0", want: "0.00"},
+		{in: "1234.56", want: "1234.56"},
+		{in: "-1234.56", want: "-1234.56"},
+		{in: "+5", want: "5.00"},
+		{in: " 7.1 ", want: "7.10"},
+		{in: ".5", want: "0.50"},
+		{in: "5.", want: "5.00"},
+		{in: "1.005", want: "1.01"},   // половина — от нуля
+		{in: "-1.005", want: "-1.01"}, // и для отрицательных
+		{in: "1.0049", want: "1.00"},
+		{in: "99.995", want: "100.00"},
+		{in: "1e+06", want: "1000000.00"},
+		{in: "-2.5E-1", want: "-0.25"},
+
+		{in: "", wantErr: true},
+		{in: "-", wantErr: true},
+		{in: ".", wantErr: true},
+		{in: "-+5", wantErr: true},
+		{in: "+-5", wantErr: true},
+		{in: "--5", wantErr: true},
+		{in: "1_000", wantErr: true},
+		{in: "1.2.3", wantErr: true},
+		{in: "1.x", wantErr: true},
+		{in: "abc", wantErr: true},
+		{in: "1e", wantErr: true},
+		{in: "99999999999999999999", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDecimal(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseDecimal(%q) = %s, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseDecimal(%q): %v", tt.in, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("ParseDecimal(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDecimalMulPercent(t *testing.T) {
+	tests := []struct {
+		amount, percent, want string
+	}{
+		{"100000.00", "3.5", "3500.00"},
+		{"100.00", "33.33", "33.33"},
+		{"0.01", "50", "0.01"},   // 0.005 → 0.01
+		{"-0.01", "50", "-0.01"}, // −0.005 → −0.01
+		{"0.01", "49.99", "0.00"},
+		{"1000", "0", "0.00"},
+		{"1000", "-2.5", "-25.00"},
+	}
+
+	for _, tt := range tests {
+		got := MustDecimal(tt.amount).MulPercent(MustDecimal(tt.percent))
+		if got.String() != tt.want {
+			t.Errorf("%s × %s%% = %s, want %s", tt.amount, tt.percent, got, tt.want)
+		}
+	}
+}
+
+func TestDecimalMulRatio(t *testing.T) {
+	tests := []struct {
+		amount, num, den, want string
+	}{
+		{"100.00", "1", "3", "33.33"},
+		{"200.00", "1", "3", "66.67"},
+		{"100.00", "2", "0", "0.00"}, // деление на ноль — ноль
+		{"-100.00", "1", "3", "-33.33"},
+	}
+
+	for _, tt := range tests {
+		got := MustDecimal(tt.amount).MulRatio(MustDecimal(tt.num), MustDecimal(tt.den))
+		if got.String() != tt.want {
+			t.Errorf("%s × %s / %s = %s, want %s", tt.amount, tt.num, tt.den, got, tt.want)
+		}
+	}
+}
+
+func TestDecimalRoundUnits(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"10.49", "10.00"},
+		{"10.50", "11.00"},
+		{"-10.50", "-11.00"},
+		{"0.49", "0.00"},
+	}
+
+	for _, tt := range tests {
+		if got := MustDecimal(tt.in).RoundUnits(); got.String() != tt.want {
+			t.Errorf("RoundUnits(%s) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDecimalAllocate(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  string
+		weights []string
+		want    []string
+	}{
+		{
+			name:    "ровно делится",
+			amount:  "100.00",
+			weights: []string{"1", "1", "2"},
+			want:    []string{"25.00", "25.00", "50.00"},
+		},
+		{
+			name:    "остаток копейки — первому при равных остатках",
+			amount:  "100.00",
+			weights: []string{"1", "1", "1"},
+			want:    []string{"33.34", "33.33", "33.33"},
+		},
+		{
+			name:    "остаток — наибольшему остатку",
+			amount:  "0.05",
+			weights: []string{"1", "3"},
+			want:    []string{"0.01", "0.04"},
+		},
+		{
+			name:    "нулевые и отрицательные веса ничего не получают",
+			amount:  "10.00",
+			weights: []string{"0", "-5", "1"},
+			want:    []string{"0.00", "0.00", "10.00"},
+		},
+		{
+			name:    "все веса нулевые",
+			amount:  "10.00",
+			weights: []string{"0", "0"},
+			want:    []string{"0.00", "0.00"},
+		},
+		{
+			name:    "отрицательная сумма",
+			amount:  "-100.00",
+			weights: []string{"1", "1", "1"},
+			want:    []string{"-33.33", "-33.33", "-33.34"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			weights := make([]Decimal, len(tt.weights))
+			for i, w := range tt.weights {
+				weights[i] = MustDecimal(w)
+			}
+
+			amount := MustDecimal(tt.amount)
+			got := amount.Allocate(weights)
+
+			var sum Decimal
+			for i := range got {
+				if got[i].String() != tt.want[i] {
+					t.Errorf("part %d = %s, want %s", i, got[i], tt.want[i])
+				}
+				sum = sum.Add(got[i])
+			}
+			if sum.Sign() != 0 && sum.Cmp(amount) != 0 {
+				t.Errorf("sum = %s, want %s", sum, amount)
+			}
+		})
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`1500`, "1500.00"},
+		{`1500.5`, "1500.50"},
+		{`"1500.50"`, "1500.50"},
+		{`"1500,50"`, "1500.50"},
+	}
+
+	for _, tt := range tests {
+		var d Decimal
+		if err := json.Unmarshal([]byte(tt.in), &d); err != nil {
+			t.Errorf("Unmarshal(%s): %v", tt.in, err)
+			continue
+		}
+		if d.String() != tt.want {
+			t.Errorf("Unmarshal(%s) = %s, want %s", tt.in, d, tt.want)
+		}
+
+		b, _ := json.Marshal(d)
+		if string(b) != tt.want {
+			t.Errorf("Marshal = %s, want %s", b, tt.want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// ========================
+//      FUND / POSITION
+// ========================
+
+// Fund — отдельный пул со своим результатом за месяц
+type Fund struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	IsDefault bool      `json:"is_default"` // сюда попадает всё без fundId
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Position — участие инвестора в фонде: его вложения и условия в этом фонде
+type Position struct {
+	ID             int64     `json:"id"`
+	FundID         int64     `json:"fund_id"`
+	InvestorID     int64     `json:"investor_id"`
+	FullName       string    `json:"full_name"`
+	InvestedAmount Decimal   `json:"invested_amount"`
+	ProfitShare    Decimal   `json:"profit_share"`
+	HighWaterMark  Decimal   `json:"high_water_mark"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Apply подставляет условия позиции в инвестора: так расчёты по фонду
+// идут теми же функциями, что и по инвестору целиком
+func (p Position) Apply(inv Investor) Investor {
+	inv.InvestedAmount = p.InvestedAmount
+	inv.ProfitShare = p.ProfitShare
+	inv.HighWaterMark = p.HighWaterMark
+	return inv
+}
+
+// ProfitShareTerm — доля прибыли позиции, действующая с EffectiveFrom
+// по EffectiveTo включительно (nil — по сей день)
+type ProfitShareTerm struct {
+	ID            int64      `json:"id"`
+	PositionID    int64      `json:"position_id"`
+	FundID        int64      `json:"fund_id"`
+	InvestorID    int64      `json:"investor_id"`
+	EffectiveFrom time.Time  `json:"effective_from"`
+	EffectiveTo   *time.Time `json:"effective_to,omitempty"`
+	ProfitShare   Decimal    `json:"profit_share"`
+	CreatedBy     *int64     `json:"created_by,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
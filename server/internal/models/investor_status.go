@@ -0,0 +1,31 @@
+package models
+
+// ========================
+//    INVESTOR STATUS
+// ========================
+
+// InvestorStatus — этап жизни инвестора.
+// active — обычная работа; suspended — без распределений, комиссий и
+// регулярных операций; closed — отношения завершены; archived — скрыт
+// из списков (DELETE), история операций сохраняется.
+type InvestorStatus string
+
+const (
+	InvestorActive    InvestorStatus = "active"
+	InvestorSuspended InvestorStatus = "suspended"
+	InvestorClosed    InvestorStatus = "closed"
+	InvestorArchived  InvestorStatus = "archived"
+)
+
+func (s InvestorStatus) Valid() bool {
+	switch s {
+	case InvestorActive, InvestorSuspended, InvestorClosed, InvestorArchived:
+		return true
+	}
+	return false
+}
+
+// Ended — closed или archived: у таких инвесторов есть closed_at
+func (s InvestorStatus) Ended() bool {
+	return s == InvestorClosed || s == InvestorArchived
+}
@@ -1,16 +1,44 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // ========================
 //       INVESTOR
 // ========================
 
+// Investor — без фильтра по фонду InvestedAmount и HighWaterMark — суммы
+// по всем позициям, ProfitShare — доля по умолчанию для новых позиций;
+// в выборке по фонду все три берутся из позиции (Position.Apply)
 type Investor struct {
 	ID             int64     `json:"id"`
 	FullName       string    `json:"full_name"`
-	InvestedAmount float64   `json:"invested_amount"`
-	ProfitShare float64 `json:"profit_share"`
+	InvestedAmount Decimal   `json:"invested_amount"`
+	ProfitShare    Decimal   `json:"profit_share"`
+
+	// комиссии: management — годовой % от капитала (списывается помесячно),
+	// performance — % от прибыли выше high-water mark
+	ManagementFeePercent  Decimal `json:"management_fee_percent"`
+	PerformanceFeePercent Decimal `json:"performance_fee_percent"`
+	HighWaterMark         Decimal `json:"high_water_mark"`
+
+	// удержание налога с прибыли: ставка в % (0 — не удерживать) и резидентство
+	TaxRate     Decimal `json:"tax_rate"`
+	TaxResident bool    `json:"tax_resident"`
+
+	Status   InvestorStatus `json:"status"`
+	ClosedAt *time.Time     `json:"closed_at,omitempty"` // для closed и archived
+
+	// контакты и заметки; пустая строка — не указано
+	Phone    string   `json:"phone"`
+	Email    string   `json:"email"`
+	Telegram string   `json:"telegram"` // без @
+	Address  string   `json:"address"`
+	Notes    string   `json:"notes"`
+	Tags     []string `json:"tags"`
+
 	CreatedAt      time.Time `json:"created_at"`
 }
 
@@ -22,16 +50,231 @@ type Payout struct {
 	ID                  int64      `json:"id"`
 	InvestorID          int64      `json:"investor_id"`
 
-	// ⚠️ Оба поля поддерживаются, потому что часть старых данных может содержать period_month
-	PeriodMonth         *time.Time `json:"period_month,omitempty"` 
-	PeriodDate          *time.Time `json:"period_date,omitempty"`
+	// позиция инвестора в фонде; при создании достаточно FundID
+	// (0 — фонд по умолчанию), позиция найдётся по инвестору
+	PositionID          int64      `json:"position_id"`
+	FundID              int64      `json:"fund_id"`
+
+	// дата операции (period_month перенесён сюда миграцией 010)
+	PeriodDate          time.Time  `json:"period_date"`
+
+	PayoutAmount        Decimal    `json:"payout_amount"`
+
+	Type                PayoutType `json:"type"`
+	FeeKind             *FeeKind   `json:"fee_kind,omitempty"` // только для type=fee
 
-	PayoutAmount        float64    `json:"payout_amount"`
+	// performance-комиссия: high-water mark позиции до неё
+	// (возвращается при сторно или удалении комиссии)
+	PrevHighWaterMark   *Decimal   `json:"prev_high_water_mark,omitempty"`
 
+	// устаревшие флаги: только для чтения, выводятся из Type
 	Reinvest            bool       `json:"reinvest"`
 	IsWithdrawalProfit  bool       `json:"is_withdrawal_profit"`
 	IsWithdrawalCapital bool       `json:"is_withdrawal_capital"`
 	IsTopup             bool       `json:"is_topup"`
 
+	// выплата создана распределением прибыли (POST /api/distributions)
+	DistributionID      *int64     `json:"distribution_id,omitempty"`
+
+	// сторно: ReversalOf — какую запись отменяет эта,
+	// ReversedBy — какой записью отменена эта
+	ReversalOf          *int64     `json:"reversal_of,omitempty"`
+	ReversalReason      *string    `json:"reversal_reason,omitempty"`
+	ReversedBy          *int64     `json:"reversed_by,omitempty"`
+
+	// удержанный налог: TaxOf — с какой выплаты прибыли;
+	// TaxOnWithdrawal — удержан из вывода прибыли и капитал не меняет
+	// (с реинвеста налог списывается из капитала)
+	TaxOf               *int64     `json:"tax_of,omitempty"`
+	TaxOnWithdrawal     bool       `json:"tax_on_withdrawal,omitempty"`
+
+	// снятие капитала: NoticeDate — когда инвестор уведомил о выводе;
+	// PenaltyOf — у строки штрафа, с какого снятия он удержан
+	NoticeDate          *time.Time `json:"notice_date,omitempty"`
+	PenaltyOf           *int64     `json:"penalty_of,omitempty"`
+
+	CreatedBy           *int64     `json:"created_by,omitempty"`
+
 	CreatedAt           time.Time  `json:"created_at"`
 }
+
+// PayoutRevision — снимок выплаты до и после правки (new_data пуст при удалении)
+type PayoutRevision struct {
+	ID        int64           `json:"id"`
+	PayoutID  int64           `json:"payout_id"`
+	Action    string          `json:"action"`
+	OldData   json.RawMessage `json:"old_data"`
+	NewData   json.RawMessage `json:"new_data,omitempty"`
+	ChangedBy *int64          `json:"changed_by,omitempty"`
+	ChangedAt time.Time       `json:"changed_at"`
+}
+
+// ========================
+//      DISTRIBUTION
+// ========================
+
+// Distribution — один запуск распределения прибыли за месяц.
+// Все его выплаты создаются и откатываются вместе.
+type Distribution struct {
+	ID           int64      `json:"id"`
+	FundID       int64      `json:"fund_id"`
+	PeriodDate   time.Time  `json:"period_date"`
+	GrossPercent Decimal    `json:"gross_percent"`
+	TotalAmount  Decimal    `json:"total_amount"`
+	DayCount     *string    `json:"day_count,omitempty"` // nil — база = капитал на дату
+	PoolAmount   *Decimal   `json:"pool_amount,omitempty"` // прибыль фонда суммой
+	HouseAmount  Decimal    `json:"house_amount"`          // досталось управляющему
+	CreatedBy    *int64     `json:"created_by,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	RolledBackAt *time.Time `json:"rolled_back_at,omitempty"`
+	RolledBackBy *int64     `json:"rolled_back_by,omitempty"`
+
+	Payouts []Payout `json:"payouts,omitempty"`
+}
+
+// ========================
+//    ACCOUNTING PERIOD
+// ========================
+
+// AccountingPeriod — месяц, закрытый после отправки отчётов инвесторам
+type AccountingPeriod struct {
+	FundID      int64      `json:"fund_id"`
+	PeriodMonth time.Time  `json:"period_month"`
+	IsClosed    bool       `json:"is_closed"`
+	ClosedAt    *time.Time `json:"closed_at,omitempty"`
+	ClosedBy    *int64     `json:"closed_by,omitempty"`
+	ReopenedAt  *time.Time `json:"reopened_at,omitempty"`
+	ReopenedBy  *int64     `json:"reopened_by,omitempty"`
+}
+
+// ========================
+//      UNITS / NAV
+// ========================
+
+// FundNAV — стоимость пая на дату
+type FundNAV struct {
+	ID            int64     `json:"id"`
+	FundID        int64     `json:"fund_id"`
+	NAVDate       time.Time `json:"nav_date"`
+	NAVPerUnit    Decimal6  `json:"nav_per_unit"`
+	TotalUnits    Decimal6  `json:"total_units"`
+	TotalAssets   Decimal   `json:"total_assets"`
+	ResultPercent *Decimal  `json:"result_percent,omitempty"`
+	CreatedBy     *int64    `json:"created_by,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// UnitTrade — покупка (+) или погашение (−) паёв инвестором
+type UnitTrade struct {
+	ID         int64     `json:"id"`
+	InvestorID int64     `json:"investor_id"`
+	PayoutID   *int64    `json:"payout_id,omitempty"`
+	TradeDate  time.Time `json:"trade_date"`
+	Units      Decimal6  `json:"units"`
+	NAVPerUnit Decimal6  `json:"nav_per_unit"`
+	Amount     Decimal   `json:"amount"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// UnitHolding — паи инвестора и их текущая стоимость
+type UnitHolding struct {
+	Investor   Investor    `json:"investor"`
+	Units      Decimal6    `json:"units"`
+	NAVPerUnit Decimal6    `json:"nav_per_unit"`
+	Value      Decimal     `json:"value"`
+	Trades     []UnitTrade `json:"trades,omitempty"`
+}
+
+// ScheduleRule — как считается сумма регулярной операции
+type ScheduleRule string
+
+const (
+	ScheduleFixed     ScheduleRule = "fixed"      // сумма Amount
+	ScheduleNetProfit ScheduleRule = "net_profit" // вся накопленная чистая прибыль
+)
+
+// Schedule — регулярная операция инвестора (пополнение или снятие раз в месяц)
+type Schedule struct {
+	ID          int64        `json:"id"`
+	InvestorID  int64        `json:"investor_id"`
+	FundID      int64        `json:"fund_id"`
+	Type        PayoutType   `json:"type"`
+	Rule        ScheduleRule `json:"rule"`
+	Amount      *Decimal     `json:"amount"` // nil для rule=net_profit
+	DayOfMonth  int          `json:"day_of_month"`
+	StartDate   time.Time    `json:"start_date"`
+	EndDate     *time.Time   `json:"end_date,omitempty"`
+	Active      bool         `json:"active"`
+	LastRunDate *time.Time   `json:"last_run_date,omitempty"` // последняя обработанная дата
+	CreatedBy   *int64       `json:"created_by,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+const (
+	ScheduleRunPosted  = "posted"
+	ScheduleRunSkipped = "skipped"
+)
+
+// ScheduleRun — обработка одной даты расписания
+type ScheduleRun struct {
+	ID         int64     `json:"id"`
+	ScheduleID int64     `json:"schedule_id"`
+	DueDate    time.Time `json:"due_date"`
+	Status     string    `json:"status"`
+	PayoutID   *int64    `json:"payout_id,omitempty"`
+	Message    *string   `json:"message,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ScheduledOperation — предстоящая операция по расписанию
+type ScheduledOperation struct {
+	ScheduleID int64        `json:"schedule_id"`
+	InvestorID int64        `json:"investor_id"`
+	FundID     int64        `json:"fund_id"`
+	Type       PayoutType   `json:"type"`
+	Rule       ScheduleRule `json:"rule"`
+	Amount     *Decimal     `json:"amount"`
+	DueDate    time.Time    `json:"due_date"`
+}
+
+// ========================
+//       DASHBOARD
+// ========================
+
+// ProfitTotals — начисленная прибыль: реинвестировано и выплачено
+type ProfitTotals struct {
+	Reinvested Decimal `json:"reinvested"`
+	Withdrawn  Decimal `json:"withdrawn"`
+	Total      Decimal `json:"total"`
+}
+
+// MonthlyFlow — чистый приток капитала за месяц
+type MonthlyFlow struct {
+	Month              time.Time `json:"month"`
+	Topups             Decimal   `json:"topups"`
+	CapitalWithdrawals Decimal   `json:"capital_withdrawals"`
+	Net                Decimal   `json:"net"`
+}
+
+// PositionShare — капитал инвестора и его доля в фонде
+type PositionShare struct {
+	InvestorID   int64   `json:"investor_id"`
+	FullName     string  `json:"full_name"`
+	Capital      Decimal `json:"capital"`
+	SharePercent Decimal `json:"share_percent"`
+}
+
+// Dashboard — сводка по фонду (GET /api/dashboard)
+type Dashboard struct {
+	Month time.Time `json:"month"` // текущий месяц для ProfitThisMonth
+
+	TotalCapital    Decimal `json:"total_capital"`
+	InvestorsCount  int     `json:"investors_count"`
+	ActiveInvestors int     `json:"active_investors"` // капитал > 0
+
+	ProfitThisMonth ProfitTotals `json:"profit_this_month"`
+	ProfitAllTime   ProfitTotals `json:"profit_all_time"`
+
+	NetInflows       []MonthlyFlow   `json:"net_inflows"`
+	LargestPositions []PositionShare `json:"largest_positions"`
+}
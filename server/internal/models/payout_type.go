@@ -0,0 +1,102 @@
+package models
+
+// ========================
+//      PAYOUT TYPE
+// ========================
+
+// PayoutType — смысл строки payouts. Заменяет четыре булевых флага;
+// флаги пока остаются в БД и API для совместимости и выводятся из типа.
+type PayoutType string
+
+const (
+	PayoutReinvest          PayoutType = "reinvest"
+	PayoutProfitWithdrawal  PayoutType = "profit_withdrawal"
+	PayoutCapitalWithdrawal PayoutType = "capital_withdrawal"
+	PayoutTopup             PayoutType = "topup"
+	PayoutFee               PayoutType = "fee"
+	PayoutAdjustment        PayoutType = "adjustment"
+	PayoutTax               PayoutType = "tax"
+)
+
+func (t PayoutType) Valid() bool {
+	switch t {
+	case PayoutReinvest, PayoutProfitWithdrawal, PayoutCapitalWithdrawal,
+		PayoutTopup, PayoutFee, PayoutAdjustment, PayoutTax:
+		return true
+	}
+	return false
+}
+
+// FeeKind — вид комиссии для строк с type=fee
+type FeeKind string
+
+const (
+	FeeManagement      FeeKind = "management"
+	FeePerformance     FeeKind = "performance"
+	FeeEarlyWithdrawal FeeKind = "early_withdrawal" // штраф за снятие капитала в lock-up
+)
+
+func (k FeeKind) Valid() bool {
+	switch k {
+	case FeeManagement, FeePerformance, FeeEarlyWithdrawal:
+		return true
+	}
+	return false
+}
+
+// PayoutTypeFromFlags — тип по старым флагам.
+// Возвращает false, если флаги противоречат друг другу (например, реинвест + снятие).
+func PayoutTypeFromFlags(reinvest, withdrawalProfit, withdrawalCapital, topup bool) (PayoutType, bool) {
+	set := 0
+	var t PayoutType
+
+	if reinvest {
+		set++
+		t = PayoutReinvest
+	}
+	if withdrawalProfit {
+		set++
+		t = PayoutProfitWithdrawal
+	}
+	if withdrawalCapital {
+		set++
+		t = PayoutCapitalWithdrawal
+	}
+	if topup {
+		set++
+		t = PayoutTopup
+	}
+
+	switch set {
+	case 0:
+		return PayoutAdjustment, true
+	case 1:
+		return t, true
+	}
+	return "", false
+}
+
+// Kind — тип строки; для старых данных без type выводится из флагов
+func (p Payout) Kind() PayoutType {
+	if p.Type != "" {
+		return p.Type
+	}
+	t, _ := PayoutTypeFromFlags(p.Reinvest, p.IsWithdrawalProfit, p.IsWithdrawalCapital, p.IsTopup)
+	return t
+}
+
+// SyncFlags выставляет Type и старые флаги согласованно
+func (p *Payout) SyncFlags() {
+	p.Type = p.Kind()
+	p.Reinvest = p.Type == PayoutReinvest
+	p.IsWithdrawalProfit = p.Type == PayoutProfitWithdrawal
+	p.IsWithdrawalCapital = p.Type == PayoutCapitalWithdrawal
+	p.IsTopup = p.Type == PayoutTopup
+
+	if p.Type != PayoutFee {
+		p.FeeKind = nil
+	}
+	if p.FeeKind == nil || *p.FeeKind != FeePerformance {
+		p.PrevHighWaterMark = nil
+	}
+}
@@ -0,0 +1,289 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"invest/internal/models"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrAgreementExists    = errors.New("agreement with this number already exists")
+	ErrNoticeTooShort     = errors.New("withdrawal notice is shorter than the agreement requires")
+	ErrNoticeRequired     = errors.New("noticeDate required for a back-dated capital withdrawal under an agreement with a notice period")
+	ErrPenaltyEntryLinked = errors.New("penalty entry is reversed or changed together with its withdrawal")
+)
+
+//
+// ========================
+//       AGREEMENTS
+// ========================
+//
+
+const agreementColumns = `id, investor_id, fund_id, number, start_date, end_date, lockup_until,
+                notice_days, penalty_percent, created_by, created_at`
+
+func scanAgreement(row rowScanner, a *models.Agreement) error {
+	return row.Scan(
+		&a.ID,
+		&a.InvestorID,
+		&a.FundID,
+		&a.Number,
+		&a.StartDate,
+		&a.EndDate,
+		&a.LockupUntil,
+		&a.NoticeDays,
+		&a.PenaltyPercent,
+		&a.CreatedBy,
+		&a.CreatedAt,
+	)
+}
+
+// ListAgreements — договоры инвестора (0 — всех), новые сверху;
+// в выборке по фонду — только договоры этого фонда
+func (r *Repository) ListAgreements(ctx context.Context, investorID int64) ([]models.Agreement, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+agreementColumns+`
+         FROM agreements
+         WHERE ($1 = 0 OR investor_id = $1) AND ($2 = 0 OR fund_id = $2)
+         ORDER BY investor_id, fund_id, start_date DESC, id DESC`, investorID, r.fundID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.Agreement
+	for rows.Next() {
+		var a models.Agreement
+		if err := scanAgreement(rows, &a); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+func (r *Repository) GetAgreement(ctx context.Context, id int64) (*models.Agreement, error) {
+	var a models.Agreement
+	err := scanAgreement(r.db.QueryRowContext(ctx,
+		`SELECT `+agreementColumns+` FROM agreements WHERE id=$1`, id), &a)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// CreateAgreement — договор по позиции инвестора в фонде a.FundID
+// (0 — по умолчанию); нет позиции — ErrNoPosition
+func (r *Repository) CreateAgreement(ctx context.Context, a *models.Agreement) error {
+	pos, err := positionFor(ctx, r.db, a.InvestorID, a.FundID, "")
+	if err != nil {
+		return err
+	}
+	a.FundID = pos.FundID
+
+	err = r.db.QueryRowContext(ctx,
+		`INSERT INTO agreements (
+            investor_id, fund_id, number, start_date, end_date, lockup_until,
+            notice_days, penalty_percent, created_by
+        )
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+         RETURNING id, created_at`,
+		a.InvestorID,
+		a.FundID,
+		a.Number,
+		a.StartDate,
+		a.EndDate,
+		a.LockupUntil,
+		a.NoticeDays,
+		a.PenaltyPercent,
+		a.CreatedBy,
+	).Scan(&a.ID, &a.CreatedAt)
+	return agreementError(err)
+}
+
+// UpdateAgreement перезаписывает условия договора; инвестор и фонд не меняются.
+// Уже начисленные штрафы остаются как есть.
+func (r *Repository) UpdateAgreement(ctx context.Context, a *models.Agreement) error {
+	err := r.db.QueryRowContext(ctx,
+		`UPDATE agreements
+         SET number=$2, start_date=$3, end_date=$4, lockup_until=$5,
+             notice_days=$6, penalty_percent=$7
+         WHERE id=$1
+         RETURNING investor_id, fund_id, created_by, created_at`,
+		a.ID,
+		a.Number,
+		a.StartDate,
+		a.EndDate,
+		a.LockupUntil,
+		a.NoticeDays,
+		a.PenaltyPercent,
+	).Scan(&a.InvestorID, &a.FundID, &a.CreatedBy, &a.CreatedAt)
+	return agreementError(err)
+}
+
+func (r *Repository) DeleteAgreement(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM agreements WHERE id=$1`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// agreementError переводит нарушения ограничений в ошибки репозитория
+func agreementError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "23505":
+			return ErrAgreementExists
+		case "23503":
+			return sql.ErrNoRows // нет такого инвестора
+		}
+	}
+	return err
+}
+
+// agreementAt — договор инвестора в фонде, действующий на дату (при
+// нескольких — начавшийся последним); sql.ErrNoRows — договора нет
+func agreementAt(ctx context.Context, q querier, investorID, fundID int64, date time.Time) (*models.Agreement, error) {
+	var a models.Agreement
+	err := scanAgreement(q.QueryRowContext(ctx,
+		`SELECT `+agreementColumns+`
+         FROM agreements
+         WHERE investor_id=$1 AND fund_id=$2 AND start_date <= $3
+           AND (end_date IS NULL OR end_date >= $3)
+         ORDER BY start_date DESC, id DESC
+         LIMIT 1`, investorID, fundID, date), &a)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+//
+// ========================
+//   ДОСРОЧНОЕ СНЯТИЕ
+// ========================
+//
+
+// checkAgreement проверяет снятие капитала по договору на дату снятия:
+// от уведомления (p.NoticeDate) до снятия должно пройти не меньше
+// notice_days. Без даты уведомления снятие сегодня или позже считается
+// заявленным сегодня; для снятия задним числом дату уведомления угадать
+// нельзя, и при сроке уведомления по договору она обязательна.
+// Возвращает договор (nil — договора нет или это не снятие капитала)
+// для chargePenalty.
+func checkAgreement(ctx context.Context, q querier, p *models.Payout) (*models.Agreement, error) {
+	if p.Kind() != models.PayoutCapitalWithdrawal || p.ReversalOf != nil {
+		p.NoticeDate = nil
+		return nil, nil
+	}
+
+	a, err := agreementAt(ctx, q, p.InvestorID, p.FundID, p.PeriodDate)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if p.NoticeDate == nil {
+		y, m, d := time.Now().Date()
+		notice := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		if p.PeriodDate.Before(notice) {
+			if a.NoticeDays > 0 {
+				return nil, fmt.Errorf("%w: agreement %s requires %d days",
+					ErrNoticeRequired, a.Number, a.NoticeDays)
+			}
+			notice = p.PeriodDate
+		}
+		p.NoticeDate = &notice
+	}
+
+	if earliest := a.EarliestWithdrawal(*p.NoticeDate); p.PeriodDate.Before(earliest) {
+		return nil, fmt.Errorf("%w: agreement %s requires %d days, earliest date %s",
+			ErrNoticeTooShort, a.Number, a.NoticeDays, earliest.Format("2006-01-02"))
+	}
+	return a, nil
+}
+
+// chargePenalty создаёт связанную строку штрафа (fee, early_withdrawal) для
+// снятия капитала src в lock-up договора a. Штраф тоже списывается из
+// капитала позиции, поэтому check проверяет его как ещё одно снятие.
+func (r *Repository) chargePenalty(ctx context.Context, tx *sql.Tx, src *models.Payout, a *models.Agreement, check CheckFunc) error {
+	if a == nil || !a.InLockup(src.PeriodDate) || a.PenaltyPercent.Sign() <= 0 {
+		return nil
+	}
+
+	amount := src.PayoutAmount.Abs().MulPercent(a.PenaltyPercent)
+	if amount.IsZero() {
+		return nil
+	}
+
+	kind := models.FeeEarlyWithdrawal
+	penalty := models.Payout{
+		InvestorID:   src.InvestorID,
+		PositionID:   src.PositionID,
+		PeriodDate:   src.PeriodDate,
+		PayoutAmount: amount,
+		Type:         models.PayoutFee,
+		FeeKind:      &kind,
+		PenaltyOf:    &src.ID,
+		CreatedBy:    src.CreatedBy,
+	}
+
+	if check != nil {
+		probe := penalty
+		probe.Type = models.PayoutCapitalWithdrawal
+		if err := checkPayout(ctx, tx, &probe, check); err != nil {
+			return fmt.Errorf("early withdrawal penalty: %w", err)
+		}
+	}
+
+	if err := insertPayout(ctx, tx, &penalty); err != nil {
+		return err
+	}
+	return r.syncUnits(ctx, tx, &penalty)
+}
+
+// reversePenalty сторнирует действующий штраф за снятие srcID
+func (r *Repository) reversePenalty(ctx context.Context, q querier, srcID int64, rev *models.Payout) error {
+	penalties, err := queryPayouts(ctx, q, "WHERE penalty_of=$1", srcID)
+	if err != nil {
+		return err
+	}
+
+	for _, pen := range penalties {
+		if pen.ReversedBy != nil {
+			continue
+		}
+
+		penRev := models.Payout{
+			InvestorID:     pen.InvestorID,
+			PositionID:     pen.PositionID,
+			PeriodDate:     rev.PeriodDate,
+			PayoutAmount:   pen.PayoutAmount.Neg(),
+			Type:           models.PayoutFee,
+			FeeKind:        pen.FeeKind,
+			ReversalOf:     &pen.ID,
+			ReversalReason: rev.ReversalReason,
+			CreatedBy:      rev.CreatedBy,
+		}
+		if err := insertPayout(ctx, q, &penRev); err != nil {
+			return err
+		}
+		if err := r.syncUnits(ctx, q, &penRev); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
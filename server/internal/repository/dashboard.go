@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"invest/internal/models"
+	"time"
+)
+
+//
+// ========================
+//       DASHBOARD
+// ========================
+//
+
+// activePayoutsCTE — операции без отменённых пар (как calc.Active);
+// tax_on_withdrawal — налог удержан из вывода прибыли и капитал не меняет.
+// $1 — фонд (0 — все фонды), поэтому остальные параметры запросов с $2.
+const activePayoutsCTE = `
+    active AS (
+        SELECT p.investor_id, p.position_id, p.type, p.payout_amount, p.period_date,
+               COALESCE(src.type = 'profit_withdrawal', FALSE) AS tax_on_withdrawal
+        FROM payouts p
+        LEFT JOIN payouts src ON src.id = p.tax_of
+        WHERE p.reversal_of IS NULL
+          AND NOT EXISTS (SELECT 1 FROM payouts rv WHERE rv.reversal_of = p.id)
+          AND ($1 = 0 OR p.position_id IN (SELECT id FROM positions WHERE fund_id = $1))
+    )`
+
+// capitalCTE — капитал каждого инвестора по тем же правилам, что calc.Summarize:
+// по каждой позиции (в фонде $1), затем сумма по инвестору
+const capitalCTE = activePayoutsCTE + `,
+    capital AS (
+        SELECT i.id, i.full_name, SUM(pc.capital) AS capital
+        FROM investors i
+        JOIN (
+            SELECT pos.investor_id,
+                   pos.invested_amount + COALESCE(SUM(CASE
+                       WHEN a.type IN ('reinvest', 'topup', 'adjustment') THEN a.payout_amount
+                       WHEN a.type IN ('capital_withdrawal', 'fee') THEN -ABS(a.payout_amount)
+                       WHEN a.type = 'tax' AND NOT a.tax_on_withdrawal THEN -ABS(a.payout_amount)
+                       ELSE 0
+                   END), 0) AS capital
+            FROM positions pos
+            LEFT JOIN active a ON a.position_id = pos.id
+            WHERE $1 = 0 OR pos.fund_id = $1
+            GROUP BY pos.id, pos.investor_id, pos.invested_amount
+        ) pc ON pc.investor_id = i.id
+        GROUP BY i.id, i.full_name
+    )`
+
+// GetDashboard собирает сводку по фонду репозитория (см. InFund; 0 — все фонды) агрегатами в базе:
+// month — текущий месяц, months — сколько последних месяцев притоков,
+// top — сколько крупнейших позиций.
+func (r *Repository) GetDashboard(ctx context.Context, month time.Time, months, top int) (*models.Dashboard, error) {
+	monthStart := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	monthEnd := monthStart.AddDate(0, 1, 0)
+
+	d := models.Dashboard{
+		Month:            monthStart,
+		NetInflows:       []models.MonthlyFlow{},
+		LargestPositions: []models.PositionShare{},
+	}
+
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx,
+			`WITH `+capitalCTE+`
+             SELECT COUNT(*),
+                    COUNT(*) FILTER (WHERE capital > 0),
+                    COALESCE(SUM(capital), 0)
+             FROM capital`, r.fundID,
+		).Scan(&d.InvestorsCount, &d.ActiveInvestors, &d.TotalCapital)
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRowContext(ctx,
+			`WITH `+activePayoutsCTE+`
+             SELECT
+                 COALESCE(SUM(ABS(payout_amount)) FILTER (WHERE type = 'reinvest'), 0),
+                 COALESCE(SUM(ABS(payout_amount)) FILTER (WHERE type = 'profit_withdrawal'), 0),
+                 COALESCE(SUM(ABS(payout_amount)) FILTER (WHERE type = 'reinvest'
+                     AND period_date >= $2 AND period_date < $3), 0),
+                 COALESCE(SUM(ABS(payout_amount)) FILTER (WHERE type = 'profit_withdrawal'
+                     AND period_date >= $2 AND period_date < $3), 0)
+             FROM active`,
+			r.fundID, monthStart, monthEnd,
+		).Scan(
+			&d.ProfitAllTime.Reinvested,
+			&d.ProfitAllTime.Withdrawn,
+			&d.ProfitThisMonth.Reinvested,
+			&d.ProfitThisMonth.Withdrawn,
+		)
+		if err != nil {
+			return err
+		}
+		d.ProfitAllTime.Total = d.ProfitAllTime.Reinvested.Add(d.ProfitAllTime.Withdrawn)
+		d.ProfitThisMonth.Total = d.ProfitThisMonth.Reinvested.Add(d.ProfitThisMonth.Withdrawn)
+
+		rows, err := tx.QueryContext(ctx,
+			`WITH `+activePayoutsCTE+`
+             SELECT date_trunc('month', period_date)::date AS month,
+                    COALESCE(SUM(ABS(payout_amount)) FILTER (WHERE type = 'topup'), 0),
+                    COALESCE(SUM(ABS(payout_amount)) FILTER (WHERE type = 'capital_withdrawal'), 0)
+             FROM active
+             WHERE type IN ('topup', 'capital_withdrawal')
+               AND period_date >= $2 AND period_date < $3
+             GROUP BY 1
+             ORDER BY 1`,
+			r.fundID, monthStart.AddDate(0, -(months-1), 0), monthEnd,
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var f models.MonthlyFlow
+			if err := rows.Scan(&f.Month, &f.Topups, &f.CapitalWithdrawals); err != nil {
+				return err
+			}
+			f.Net = f.Topups.Sub(f.CapitalWithdrawals)
+			d.NetInflows = append(d.NetInflows, f)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		posRows, err := tx.QueryContext(ctx,
+			`WITH `+capitalCTE+`
+             SELECT id, full_name, capital
+             FROM capital
+             WHERE capital > 0
+             ORDER BY capital DESC, id
+             LIMIT $2`, r.fundID, top)
+		if err != nil {
+			return err
+		}
+		defer posRows.Close()
+
+		for posRows.Next() {
+			var p models.PositionShare
+			if err := posRows.Scan(&p.InvestorID, &p.FullName, &p.Capital); err != nil {
+				return err
+			}
+			p.SharePercent = models.DecimalFromInt(100).MulRatio(p.Capital, d.TotalCapital)
+			d.LargestPositions = append(d.LargestPositions, p)
+		}
+		return posRows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &d, nil
+}
@@ -0,0 +1,260 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"invest/internal/models"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrDistributionRolledBack = errors.New("distribution already rolled back")
+	ErrDistributionExists     = errors.New("fund already has an active distribution for this month")
+	ErrDistributionUnitMode   = errors.New("fund is in units mode: record the month's result with POST /api/nav")
+)
+
+// PlanFunc получает актуальных инвесторов и все выплаты (внутри транзакции)
+// и возвращает выплаты, которые нужно записать.
+type PlanFunc func(investors []models.Investor, payouts []models.Payout) ([]models.Payout, error)
+
+//
+// ========================
+//      DISTRIBUTIONS
+// ========================
+//
+
+// CreateDistribution записывает запуск и все его выплаты в одной транзакции.
+// Распределение идёт внутри фонда d.FundID (0 — по умолчанию): plan получает
+// его участников с условиями позиций (сумма вложений и доля — на
+// d.PeriodDate) и операции только этого фонда.
+// Инвесторы блокируются (FOR UPDATE), поэтому капитал, от которого
+// считает plan, не может измениться до commit.
+func (r *Repository) CreateDistribution(ctx context.Context, d *models.Distribution, plan PlanFunc) error {
+	// в паевом режиме прибыль двигает NAV; строки reinvest посчитали бы её второй раз
+	if r.unitMode {
+		return ErrDistributionUnitMode
+	}
+
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		fundID, err := resolveFundID(ctx, tx, d.FundID)
+		if err != nil {
+			return err
+		}
+		d.FundID = fundID
+
+		if err := ensurePeriodOpen(ctx, tx, d.FundID, d.PeriodDate); err != nil {
+			return err
+		}
+
+		// повторный запуск за тот же месяц выплатил бы всем второй раз;
+		// сначала нужно откатить прежний (параллельный запуск ловит индекс)
+		var exists bool
+		err = tx.QueryRowContext(ctx,
+			`SELECT EXISTS (
+                 SELECT 1 FROM distributions
+                 WHERE fund_id=$1 AND rolled_back_at IS NULL
+                   AND date_trunc('month', period_date::timestamp)
+                     = date_trunc('month', $2::date::timestamp)
+             )`, d.FundID, d.PeriodDate,
+		).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ErrDistributionExists
+		}
+
+		// приостановленные, закрытые и архивные в расчёт не попадают
+		investors, err := listInvestorsIn(ctx, tx, d.FundID,
+			"WHERE status=$1", "FOR UPDATE", models.InvestorActive)
+		if err != nil {
+			return err
+		}
+		// сумма вложений и доля — те, что действовали в распределяемом месяце
+		if err := applyTermsAt(ctx, tx, d.FundID, d.PeriodDate, investors); err != nil {
+			return err
+		}
+
+		where, args := payoutsWhere(d.FundID, "", nil)
+		all, err := queryPayouts(ctx, tx, where, args...)
+		if err != nil {
+			return err
+		}
+
+		payouts, err := plan(investors, all)
+		if err != nil {
+			return err
+		}
+
+		var total models.Decimal
+		for _, p := range payouts {
+			total = total.Add(p.PayoutAmount)
+		}
+		d.TotalAmount = total
+
+		err = tx.QueryRowContext(ctx,
+			`INSERT INTO distributions (
+                period_date, gross_percent, total_amount, day_count,
+                pool_amount, house_amount, created_by, fund_id
+            )
+             VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+             RETURNING id, created_at`,
+			d.PeriodDate,
+			d.GrossPercent,
+			d.TotalAmount,
+			d.DayCount,
+			d.PoolAmount,
+			d.HouseAmount,
+			d.CreatedBy,
+			d.FundID,
+		).Scan(&d.ID, &d.CreatedAt)
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return ErrDistributionExists
+		}
+		if err != nil {
+			return err
+		}
+
+		d.Payouts = make([]models.Payout, 0, len(payouts))
+		for _, p := range payouts {
+			p.DistributionID = &d.ID
+			p.FundID = d.FundID
+			if err := insertPayout(ctx, tx, &p); err != nil {
+				return err
+			}
+			if err := r.withholdTax(ctx, tx, &p); err != nil {
+				return err
+			}
+			d.Payouts = append(d.Payouts, p)
+		}
+
+		return nil
+	})
+}
+
+// ListDistributions — запуски фонда репозитория (см. InFund), новые сверху
+func (r *Repository) ListDistributions(ctx context.Context) ([]models.Distribution, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+distributionColumns+`
+         FROM distributions
+         WHERE $1 = 0 OR fund_id = $1
+         ORDER BY period_date DESC, id DESC`, r.fundID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.Distribution
+	for rows.Next() {
+		var d models.Distribution
+		if err := scanDistribution(rows, &d); err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// GetDistribution возвращает запуск вместе с его выплатами
+func (r *Repository) GetDistribution(ctx context.Context, id int64) (*models.Distribution, error) {
+	var d models.Distribution
+
+	row := r.db.QueryRowContext(ctx,
+		`SELECT `+distributionColumns+` FROM distributions WHERE id=$1`, id)
+	if err := scanDistribution(row, &d); err != nil {
+		return nil, err
+	}
+
+	payouts, err := queryPayouts(ctx, r.db, "WHERE distribution_id=$1", id)
+	if err != nil {
+		return nil, err
+	}
+	d.Payouts = payouts
+
+	return &d, nil
+}
+
+// RollbackDistribution удаляет все выплаты запуска (с налогом и сторно)
+// и помечает его откатанным; каждая удалённая строка остаётся в payout_revisions
+func (r *Repository) RollbackDistribution(ctx context.Context, id, userID int64) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		var rolledBack sql.NullTime
+		var period time.Time
+		var fundID int64
+		err := tx.QueryRowContext(ctx,
+			`SELECT rolled_back_at, period_date, fund_id FROM distributions WHERE id=$1 FOR UPDATE`, id,
+		).Scan(&rolledBack, &period, &fundID)
+		if err != nil {
+			return err
+		}
+		if rolledBack.Valid {
+			return ErrDistributionRolledBack
+		}
+
+		if err := ensurePeriodOpen(ctx, tx, fundID, period); err != nil {
+			return err
+		}
+
+		// сторно выплат запуска и их налога (сделанные до запрета) удаляются
+		// первыми: они ссылаются на исходные; налог — вместе со своей выплатой
+		for _, where := range []string{
+			`WHERE reversal_of IN (
+                 SELECT id FROM payouts
+                 WHERE distribution_id=$1
+                    OR tax_of IN (SELECT id FROM payouts WHERE distribution_id=$1)
+             )`,
+			`WHERE distribution_id=$1 AND reversal_of IS NULL`,
+		} {
+			payouts, err := queryPayouts(ctx, tx, where, id)
+			if err != nil {
+				return err
+			}
+			for i := range payouts {
+				if err := deletePayout(ctx, tx, &payouts[i], userID); err != nil {
+					return err
+				}
+			}
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`UPDATE distributions
+             SET rolled_back_at=NOW(), rolled_back_by=$2
+             WHERE id=$1`,
+			id, nullableID(userID))
+		return err
+	})
+}
+
+// distributionColumns — порядок колонок совпадает со scanDistribution
+const distributionColumns = `id, fund_id, period_date, gross_percent, total_amount, day_count,
+                pool_amount, house_amount, created_by,
+                created_at, rolled_back_at, rolled_back_by`
+
+func scanDistribution(row rowScanner, d *models.Distribution) error {
+	return row.Scan(
+		&d.ID,
+		&d.FundID,
+		&d.PeriodDate,
+		&d.GrossPercent,
+		&d.TotalAmount,
+		&d.DayCount,
+		&d.PoolAmount,
+		&d.HouseAmount,
+		&d.CreatedBy,
+		&d.CreatedAt,
+		&d.RolledBackAt,
+		&d.RolledBackBy,
+	)
+}
+
+// nullableID — 0 означает «пользователь неизвестен» и пишется как NULL
+func nullableID(id int64) *int64 {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
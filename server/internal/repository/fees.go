@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"invest/internal/models"
+	"time"
+)
+
+var ErrLaterPerformanceFee = errors.New("position has a later performance fee: reverse or delete it first")
+
+// FeePlanFunc получает инвесторов и выплаты (внутри транзакции) и возвращает
+// строки комиссий и новые high-water mark по инвесторам.
+type FeePlanFunc func(investors []models.Investor, payouts []models.Payout) ([]models.Payout, map[int64]models.Decimal, error)
+
+//
+// ========================
+//         FEES
+// ========================
+//
+
+// ChargeFees записывает комиссии за месяц по фонду fundID (0 — по умолчанию)
+// и обновляет high-water mark позиций в одной транзакции.
+// Инвесторы блокируются так же, как при распределении.
+func (r *Repository) ChargeFees(ctx context.Context, fundID int64, date time.Time, plan FeePlanFunc) ([]models.Payout, error) {
+	var out []models.Payout
+
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		fundID, err := resolveFundID(ctx, tx, fundID)
+		if err != nil {
+			return err
+		}
+
+		if err := ensurePeriodOpen(ctx, tx, fundID, date); err != nil {
+			return err
+		}
+
+		// приостановленные, закрытые и архивные в расчёт не попадают
+		investors, err := listInvestorsIn(ctx, tx, fundID,
+			"WHERE status=$1", "FOR UPDATE", models.InvestorActive)
+		if err != nil {
+			return err
+		}
+		// база — сумма вложений, действовавшая на дату комиссии
+		if err := applyTermsAt(ctx, tx, fundID, date, investors); err != nil {
+			return err
+		}
+
+		where, args := payoutsWhere(fundID, "", nil)
+		all, err := queryPayouts(ctx, tx, where, args...)
+		if err != nil {
+			return err
+		}
+
+		payouts, marks, err := plan(investors, all)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range payouts {
+			p.FundID = fundID
+			if err := insertPayout(ctx, tx, &p); err != nil {
+				return err
+			}
+			if err := r.syncUnits(ctx, tx, &p); err != nil {
+				return err
+			}
+			out = append(out, p)
+		}
+
+		for id, hwm := range marks {
+			if _, err := tx.ExecContext(ctx,
+				`UPDATE positions SET high_water_mark=$3
+                 WHERE investor_id=$1 AND fund_id=$2`, id, fundID, hwm); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return out, err
+}
+
+// restoreHighWaterMark возвращает позиции high-water mark, действовавший до
+// performance-комиссии p, когда она сторнируется или удаляется. Более поздняя
+// комиссия считалась от отметки p, поэтому сначала нужно отменить её.
+func restoreHighWaterMark(ctx context.Context, q querier, p *models.Payout) error {
+	if p.Kind() != models.PayoutFee || p.FeeKind == nil || *p.FeeKind != models.FeePerformance ||
+		p.PrevHighWaterMark == nil || p.ReversalOf != nil {
+		return nil
+	}
+
+	var later bool
+	err := q.QueryRowContext(ctx,
+		`SELECT EXISTS (
+             SELECT 1 FROM payouts f
+             WHERE f.position_id=$1 AND f.type=$2 AND f.fee_kind=$3
+               AND f.reversal_of IS NULL
+               AND NOT EXISTS (SELECT 1 FROM payouts rv WHERE rv.reversal_of = f.id)
+               AND (f.period_date, f.id) > ($4::date, $5::int)
+         )`,
+		p.PositionID, models.PayoutFee, models.FeePerformance, p.PeriodDate, p.ID,
+	).Scan(&later)
+	if err != nil {
+		return err
+	}
+	if later {
+		return ErrLaterPerformanceFee
+	}
+
+	_, err = q.ExecContext(ctx,
+		`UPDATE positions SET high_water_mark=$2 WHERE id=$1`,
+		p.PositionID, *p.PrevHighWaterMark)
+	return err
+}
@@ -0,0 +1,304 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"invest/internal/models"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrNoPosition     = errors.New("investor has no position in this fund")
+	ErrPositionExists = errors.New("investor already has a position in this fund")
+	ErrFundExists     = errors.New("fund with this name already exists")
+	ErrFundNotFound   = errors.New("fund not found")
+)
+
+//
+// ========================
+//         FUNDS
+// ========================
+//
+
+// InFund — тот же репозиторий, но выборки инвесторов, выплат, распределений,
+// расписаний и дашборда ограничены фондом fundID (0 — все фонды).
+// Инвесторы в такой выборке несут условия своей позиции в фонде.
+func (r *Repository) InFund(fundID int64) *Repository {
+	scoped := *r
+	scoped.fundID = fundID
+	return &scoped
+}
+
+func (r *Repository) ListFunds(ctx context.Context) ([]models.Fund, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, name, is_default, created_at FROM funds ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.Fund
+	for rows.Next() {
+		var f models.Fund
+		if err := rows.Scan(&f.ID, &f.Name, &f.IsDefault, &f.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
+
+func (r *Repository) GetFund(ctx context.Context, id int64) (*models.Fund, error) {
+	var f models.Fund
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id, name, is_default, created_at FROM funds WHERE id=$1`, id,
+	).Scan(&f.ID, &f.Name, &f.IsDefault, &f.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func (r *Repository) CreateFund(ctx context.Context, f *models.Fund) error {
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO funds (name) VALUES ($1)
+         RETURNING id, is_default, created_at`, f.Name,
+	).Scan(&f.ID, &f.IsDefault, &f.CreatedAt)
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrFundExists
+	}
+	return err
+}
+
+// ResolveFundID — id существующего фонда; 0 — фонд по умолчанию
+func (r *Repository) ResolveFundID(ctx context.Context, id int64) (int64, error) {
+	return resolveFundID(ctx, r.db, id)
+}
+
+func resolveFundID(ctx context.Context, q querier, id int64) (int64, error) {
+	if id == 0 {
+		err := q.QueryRowContext(ctx,
+			`SELECT id FROM funds WHERE is_default`).Scan(&id)
+		return id, err
+	}
+
+	err := q.QueryRowContext(ctx,
+		`SELECT id FROM funds WHERE id=$1`, id).Scan(&id)
+	return id, err
+}
+
+//
+// ========================
+//        POSITIONS
+// ========================
+//
+
+const positionColumns = `pos.id, pos.fund_id, pos.investor_id, i.full_name,
+                pos.invested_amount, pos.profit_share, pos.high_water_mark, pos.created_at`
+
+func scanPosition(row rowScanner, pos *models.Position) error {
+	return row.Scan(
+		&pos.ID,
+		&pos.FundID,
+		&pos.InvestorID,
+		&pos.FullName,
+		&pos.InvestedAmount,
+		&pos.ProfitShare,
+		&pos.HighWaterMark,
+		&pos.CreatedAt,
+	)
+}
+
+func queryPositions(ctx context.Context, q querier, where string, args ...any) ([]models.Position, error) {
+	rows, err := q.QueryContext(ctx,
+		`SELECT `+positionColumns+`
+         FROM positions pos
+         JOIN investors i ON i.id = pos.investor_id
+         `+where+`
+         ORDER BY pos.fund_id, pos.investor_id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.Position
+	for rows.Next() {
+		var pos models.Position
+		if err := scanPosition(rows, &pos); err != nil {
+			return nil, err
+		}
+		out = append(out, pos)
+	}
+	return out, rows.Err()
+}
+
+// ListPositions — позиции фонда
+func (r *Repository) ListPositions(ctx context.Context, fundID int64) ([]models.Position, error) {
+	return queryPositions(ctx, r.db, "WHERE pos.fund_id=$1", fundID)
+}
+
+// ListInvestorPositions — в каких фондах участвует инвестор
+func (r *Repository) ListInvestorPositions(ctx context.Context, investorID int64) ([]models.Position, error) {
+	return queryPositions(ctx, r.db, "WHERE pos.investor_id=$1", investorID)
+}
+
+// CreatePosition вводит инвестора в фонд; FundID = 0 — фонд по умолчанию
+func (r *Repository) CreatePosition(ctx context.Context, pos *models.Position) error {
+	return createPosition(ctx, r.db, pos)
+}
+
+func createPosition(ctx context.Context, q querier, pos *models.Position) error {
+	fundID, err := resolveFundID(ctx, q, pos.FundID)
+	if err != nil {
+		return err
+	}
+	pos.FundID = fundID
+
+	err = q.QueryRowContext(ctx,
+		`INSERT INTO positions (fund_id, investor_id, invested_amount, profit_share)
+         VALUES ($1, $2, $3, $4)
+         RETURNING id, high_water_mark, created_at,
+                   (SELECT full_name FROM investors WHERE id = $2)`,
+		pos.FundID,
+		pos.InvestorID,
+		pos.InvestedAmount,
+		pos.ProfitShare,
+	).Scan(&pos.ID, &pos.HighWaterMark, &pos.CreatedAt, &pos.FullName)
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "23505":
+			return ErrPositionExists
+		case "23503":
+			return sql.ErrNoRows // нет такого инвестора
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	// первая запись истории доли; действует и для более ранних периодов
+	_, err = q.ExecContext(ctx,
+		`INSERT INTO profit_share_terms (position_id, effective_from, profit_share)
+         VALUES ($1, $2, $3)`,
+		pos.ID, pos.CreatedAt, pos.ProfitShare)
+	if err != nil {
+		return err
+	}
+
+	// и первая запись истории суммы вложений
+	_, err = q.ExecContext(ctx,
+		`INSERT INTO invested_amount_terms (position_id, effective_from, invested_amount)
+         VALUES ($1, $2, $3)`,
+		pos.ID, pos.CreatedAt, pos.InvestedAmount)
+	return err
+}
+
+// positionFor — позиция инвестора в фонде (0 — по умолчанию);
+// suffix = "FOR UPDATE" блокирует её
+func positionFor(ctx context.Context, q querier, investorID, fundID int64, suffix string) (*models.Position, error) {
+	fundID, err := resolveFundID(ctx, q, fundID)
+	if err != nil {
+		return nil, err
+	}
+
+	var pos models.Position
+	err = scanPosition(q.QueryRowContext(ctx,
+		`SELECT `+positionColumns+`
+         FROM positions pos
+         JOIN investors i ON i.id = pos.investor_id
+         WHERE pos.investor_id=$1 AND pos.fund_id=$2 `+suffix,
+		investorID, fundID,
+	), &pos)
+	if err == sql.ErrNoRows {
+		return nil, ErrNoPosition
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &pos, nil
+}
+
+// resolvePosition заполняет PositionID и FundID операции: по уже известной
+// позиции или по инвестору и фонду (FundID = 0 — фонд по умолчанию)
+func resolvePosition(ctx context.Context, q querier, p *models.Payout) error {
+	if p.PositionID != 0 {
+		err := q.QueryRowContext(ctx,
+			`SELECT fund_id FROM positions WHERE id=$1 AND investor_id=$2`,
+			p.PositionID, p.InvestorID,
+		).Scan(&p.FundID)
+		if err == sql.ErrNoRows {
+			return ErrNoPosition
+		}
+		return err
+	}
+
+	pos, err := positionFor(ctx, q, p.InvestorID, p.FundID, "")
+	if err != nil {
+		return err
+	}
+	p.PositionID = pos.ID
+	p.FundID = pos.FundID
+	return nil
+}
+
+//
+// ========================
+//      ВЫБОРКИ ПО ФОНДУ
+// ========================
+//
+
+// andWhere дописывает условие к "WHERE ..." (или начинает его)
+func andWhere(where, cond string) string {
+	if where == "" {
+		return "WHERE " + cond
+	}
+	return where + " AND " + cond
+}
+
+// payoutsWhere ограничивает выборку payouts фондом fundID (0 — без ограничения)
+func payoutsWhere(fundID int64, where string, args []any) (string, []any) {
+	if fundID == 0 {
+		return where, args
+	}
+	args = append(args, fundID)
+	return andWhere(where, fmt.Sprintf(
+		"position_id IN (SELECT id FROM positions WHERE fund_id=$%d)", len(args))), args
+}
+
+// listInvestorsIn — listInvestors по фонду: только участники фонда,
+// сумма вложений, доля и HWM — из их позиций
+func listInvestorsIn(ctx context.Context, q querier, fundID int64, where, suffix string, args ...any) ([]models.Investor, error) {
+	if fundID == 0 {
+		return listInvestors(ctx, q, where, suffix, args...)
+	}
+
+	args = append(args, fundID)
+	where = andWhere(where, fmt.Sprintf(
+		"id IN (SELECT investor_id FROM positions WHERE fund_id=$%d)", len(args)))
+
+	investors, err := listInvestors(ctx, q, where, suffix, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	positions, err := queryPositions(ctx, q, "WHERE pos.fund_id=$1", fundID)
+	if err != nil {
+		return nil, err
+	}
+	byInvestor := make(map[int64]models.Position, len(positions))
+	for _, pos := range positions {
+		byInvestor[pos.InvestorID] = pos
+	}
+
+	for i, inv := range investors {
+		investors[i] = byInvestor[inv.ID].Apply(inv)
+	}
+	return investors, nil
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"invest/internal/models"
+	"time"
+)
+
+var ErrPeriodClosed = errors.New("accounting period is closed")
+
+//
+// ========================
+//   ОТЧЁТНЫЕ ПЕРИОДЫ
+// ========================
+//
+
+// ListPeriods — закрытые и переоткрытые месяцы; в выборке по фонду — только его
+func (r *Repository) ListPeriods(ctx context.Context) ([]models.AccountingPeriod, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT fund_id, period_month, is_closed, closed_at, closed_by, reopened_at, reopened_by
+         FROM accounting_periods
+         WHERE $1 = 0 OR fund_id = $1
+         ORDER BY period_month DESC, fund_id`, r.fundID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.AccountingPeriod
+	for rows.Next() {
+		var p models.AccountingPeriod
+		if err := rows.Scan(
+			&p.FundID,
+			&p.PeriodMonth,
+			&p.IsClosed,
+			&p.ClosedAt,
+			&p.ClosedBy,
+			&p.ReopenedAt,
+			&p.ReopenedBy,
+		); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// ClosePeriod закрывает в фонде fundID (0 — по умолчанию) месяц,
+// в который попадает month
+func (r *Repository) ClosePeriod(ctx context.Context, fundID int64, month time.Time, userID int64) error {
+	fundID, err := resolveFundID(ctx, r.db, fundID)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx,
+		`INSERT INTO accounting_periods (fund_id, period_month, is_closed, closed_at, closed_by)
+         VALUES ($1, date_trunc('month', $2::date)::date, TRUE, NOW(), $3)
+         ON CONFLICT (fund_id, period_month) DO UPDATE
+         SET is_closed=TRUE, closed_at=NOW(), closed_by=$3`,
+		fundID, month, nullableID(userID))
+	return err
+}
+
+// ReopenPeriod снова открывает месяц фонда; история закрытия сохраняется
+func (r *Repository) ReopenPeriod(ctx context.Context, fundID int64, month time.Time, userID int64) error {
+	fundID, err := resolveFundID(ctx, r.db, fundID)
+	if err != nil {
+		return err
+	}
+
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE accounting_periods
+         SET is_closed=FALSE, reopened_at=NOW(), reopened_by=$3
+         WHERE fund_id=$1 AND period_month = date_trunc('month', $2::date)::date AND is_closed`,
+		fundID, month, nullableID(userID))
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ensurePeriodOpen возвращает ErrPeriodClosed, если дата попадает в закрытый
+// месяц фонда fundID. Строка периода читается FOR SHARE, чтобы закрытие
+// ждало конца записи.
+func ensurePeriodOpen(ctx context.Context, q querier, fundID int64, date time.Time) error {
+	var closed bool
+	err := q.QueryRowContext(ctx,
+		`SELECT is_closed FROM accounting_periods
+         WHERE fund_id=$1 AND period_month = date_trunc('month', $2::date)::date
+         FOR SHARE`,
+		fundID, date,
+	).Scan(&closed)
+
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if closed {
+		return fmt.Errorf("%w: %s", ErrPeriodClosed, date.Format("2006-01"))
+	}
+	return nil
+}
@@ -3,27 +3,173 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"invest/internal/models"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrInvestorArchived    = errors.New("investor is archived")
+	ErrInvestorNotArchived = errors.New("investor must be archived before purge")
 )
 
 type Repository struct {
 	db *sql.DB
+
+	// паевой учёт: пополнения и снятия капитала покупают/гасят паи
+	unitMode bool
+
+	// фонд, которым ограничены выборки (см. InFund); 0 — все фонды
+	fundID int64
 }
 
 func New(db *sql.DB) *Repository {
 	return &Repository{db: db}
 }
 
+func (r *Repository) SetUnitMode(enabled bool) {
+	r.unitMode = enabled
+}
+
+func (r *Repository) UnitMode() bool {
+	return r.unitMode
+}
+
+// querier — общее у *sql.DB и *sql.Tx, чтобы одни и те же запросы
+// работали и отдельно, и внутри транзакции
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// rowScanner — общее у *sql.Row и *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// inTx выполняет fn в транзакции: commit при успехе, rollback при ошибке
+func (r *Repository) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
 //
 // ========================
 //      INVESTORS
 // ========================
 //
 
+// ListInvestors — все инвесторы, включая архивных
 func (r *Repository) ListInvestors(ctx context.Context) ([]models.Investor, error) {
-	rows, err := r.db.QueryContext(ctx,
-		`SELECT id, full_name, invested_amount, profit_share, created_at
-         FROM investors ORDER BY id`)
+	return listInvestorsIn(ctx, r.db, r.fundID, "", "")
+}
+
+// ListInvestorsByStatus — инвесторы с одним из статусов
+func (r *Repository) ListInvestorsByStatus(ctx context.Context, statuses ...models.InvestorStatus) ([]models.Investor, error) {
+	return r.FindInvestors(ctx, InvestorFilter{Statuses: statuses})
+}
+
+// InvestorFilter — условия GET /api/investors; пустые поля не фильтруют
+type InvestorFilter struct {
+	Statuses []models.InvestorStatus // любой из статусов
+	Tags     []string                // все теги сразу
+
+	// сумма вложений и доля прибыли — действовавшие на эту дату, а не текущие
+	TermsAt *time.Time
+}
+
+func (r *Repository) FindInvestors(ctx context.Context, f InvestorFilter) ([]models.Investor, error) {
+	var conds []string
+	var args []any
+
+	if len(f.Statuses) > 0 {
+		list := make([]string, len(f.Statuses))
+		for i, s := range f.Statuses {
+			list[i] = string(s)
+		}
+		args = append(args, pq.Array(list))
+		conds = append(conds, fmt.Sprintf("status = ANY($%d)", len(args)))
+	}
+	if len(f.Tags) > 0 {
+		args = append(args, pq.Array(f.Tags))
+		conds = append(conds, fmt.Sprintf("tags @> $%d", len(args)))
+	}
+
+	where := ""
+	if len(conds) > 0 {
+		where = "WHERE " + strings.Join(conds, " AND ")
+	}
+	investors, err := listInvestorsIn(ctx, r.db, r.fundID, where, "", args...)
+	if err != nil || f.TermsAt == nil {
+		return investors, err
+	}
+	return investors, applyTermsAt(ctx, r.db, r.fundID, *f.TermsAt, investors)
+}
+
+// ListInvestorsAsOf — ListInvestors с суммой вложений и долей,
+// действовавшими на дату asOf
+func (r *Repository) ListInvestorsAsOf(ctx context.Context, asOf time.Time) ([]models.Investor, error) {
+	investors, err := listInvestorsIn(ctx, r.db, r.fundID, "", "")
+	if err != nil {
+		return nil, err
+	}
+	return investors, applyTermsAt(ctx, r.db, r.fundID, asOf, investors)
+}
+
+// investorColumns — порядок колонок совпадает со scanInvestor;
+// вложения и HWM — суммы по позициям во всех фондах
+const investorColumns = `id, full_name,
+                (SELECT COALESCE(SUM(invested_amount), 0) FROM positions
+                 WHERE investor_id = investors.id) AS invested_amount,
+                profit_share,
+                management_fee_percent, performance_fee_percent,
+                (SELECT COALESCE(SUM(high_water_mark), 0) FROM positions
+                 WHERE investor_id = investors.id) AS high_water_mark,
+                tax_rate, tax_resident, status, closed_at,
+                phone, email, telegram, address, notes, tags, created_at`
+
+func scanInvestor(row rowScanner, inv *models.Investor) error {
+	return row.Scan(
+		&inv.ID,
+		&inv.FullName,
+		&inv.InvestedAmount,
+		&inv.ProfitShare,
+		&inv.ManagementFeePercent,
+		&inv.PerformanceFeePercent,
+		&inv.HighWaterMark,
+		&inv.TaxRate,
+		&inv.TaxResident,
+		&inv.Status,
+		&inv.ClosedAt,
+		&inv.Phone,
+		&inv.Email,
+		&inv.Telegram,
+		&inv.Address,
+		&inv.Notes,
+		pq.Array(&inv.Tags),
+		&inv.CreatedAt,
+	)
+}
+
+// listInvestors — where фильтрует строки, suffix = "FOR UPDATE" блокирует их
+func listInvestors(ctx context.Context, q querier, where, suffix string, args ...any) ([]models.Investor, error) {
+	rows, err := q.QueryContext(ctx,
+		`SELECT `+investorColumns+`
+         FROM investors `+where+` ORDER BY id `+suffix, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -32,92 +178,313 @@ func (r *Repository) ListInvestors(ctx context.Context) ([]models.Investor, erro
 	var out []models.Investor
 	for rows.Next() {
 		var inv models.Investor
-		if err := rows.Scan(
-			&inv.ID,
-			&inv.FullName,
-			&inv.InvestedAmount,
-			&inv.ProfitShare,
-			&inv.CreatedAt,
-		); err != nil {
+		if err := scanInvestor(rows, &inv); err != nil {
 			return nil, err
 		}
 		out = append(out, inv)
 	}
-	return out, nil
-}
-
-func (r *Repository) CreateInvestor(ctx context.Context, inv *models.Investor) error {
-	return r.db.QueryRowContext(ctx,
-		`INSERT INTO investors (full_name, invested_amount, profit_share)
-         VALUES ($1, $2, $3)
-         RETURNING id, full_name, invested_amount, profit_share, created_at`,
-		inv.FullName,
-		inv.InvestedAmount,
-		inv.ProfitShare,
-	).Scan(
-		&inv.ID,
-		&inv.FullName,
-		&inv.InvestedAmount,
-		&inv.ProfitShare,
-		&inv.CreatedAt,
-	)
+	return out, rows.Err()
 }
 
-func (r *Repository) UpdateInvestor(
-	ctx context.Context,
-	id int64,
-	fullName *string,
-	investedAmount *float64,
-	profitShare *float64,
-) error {
-	if fullName != nil {
-		_, err := r.db.ExecContext(ctx,
-			`UPDATE investors SET full_name=$1 WHERE id=$2`,
-			*fullName, id)
+// CreateInvestor создаёт инвестора и его позицию в фонде fundID
+// (0 — фонд по умолчанию) с InvestedAmount и ProfitShare
+func (r *Repository) CreateInvestor(ctx context.Context, inv *models.Investor, fundID int64) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx,
+			`INSERT INTO investors (
+                full_name, profit_share,
+                management_fee_percent, performance_fee_percent,
+                tax_rate, tax_resident,
+                phone, email, telegram, address, notes, tags
+            )
+             VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+             RETURNING id`,
+			inv.FullName,
+			inv.ProfitShare,
+			inv.ManagementFeePercent,
+			inv.PerformanceFeePercent,
+			inv.TaxRate,
+			inv.TaxResident,
+			inv.Phone,
+			inv.Email,
+			inv.Telegram,
+			inv.Address,
+			inv.Notes,
+			pq.Array(inv.Tags),
+		).Scan(&inv.ID)
 		if err != nil {
 			return err
 		}
+
+		pos := models.Position{
+			FundID:         fundID,
+			InvestorID:     inv.ID,
+			InvestedAmount: inv.InvestedAmount,
+			ProfitShare:    inv.ProfitShare,
+		}
+		if err := createPosition(ctx, tx, &pos); err != nil {
+			return err
+		}
+
+		return scanInvestor(tx.QueryRowContext(ctx,
+			`SELECT `+investorColumns+` FROM investors WHERE id=$1`, inv.ID), inv)
+	})
+}
+
+// InvestorPatch — поля для PUT /api/investors/{id}; nil — не менять.
+// InvestedAmount и ProfitShare меняют позицию в фонде FundID (0 — по умолчанию);
+// ProfitShare в фонде по умолчанию — ещё и доля для новых позиций.
+type InvestorPatch struct {
+	FundID int64
+
+	FullName              *string
+	InvestedAmount        *models.Decimal
+	ProfitShare           *models.Decimal
+	ManagementFeePercent  *models.Decimal
+	PerformanceFeePercent *models.Decimal
+	TaxRate               *models.Decimal
+	TaxResident           *bool
+
+	// смена статуса; ClosedAt для closed/archived, по умолчанию — сегодня
+	Status   *models.InvestorStatus
+	ClosedAt *time.Time
+
+	Phone    *string
+	Email    *string
+	Telegram *string
+	Address  *string
+	Notes    *string
+	Tags     *[]string // заменяет список целиком
+
+	// с какой даты действуют новые ProfitShare и InvestedAmount
+	// (по умолчанию — сегодня) и кто их поменял; прежние остаются в истории
+	ProfitShareFrom    *time.Time
+	InvestedAmountFrom *time.Time
+	ChangedBy          *int64
+}
+
+func (r *Repository) UpdateInvestor(ctx context.Context, id int64, patch InvestorPatch) error {
+	var sets []string
+	var args []any
+
+	set := func(col string, v any) {
+		args = append(args, v)
+		sets = append(sets, fmt.Sprintf("%s=$%d", col, len(args)))
 	}
 
-	if investedAmount != nil {
-		_, err := r.db.ExecContext(ctx,
-			`UPDATE investors SET invested_amount=$1 WHERE id=$2`,
-			*investedAmount, id)
-		if err != nil {
+	if patch.FullName != nil {
+		set("full_name", *patch.FullName)
+	}
+	if patch.ManagementFeePercent != nil {
+		set("management_fee_percent", *patch.ManagementFeePercent)
+	}
+	if patch.PerformanceFeePercent != nil {
+		set("performance_fee_percent", *patch.PerformanceFeePercent)
+	}
+	if patch.TaxRate != nil {
+		set("tax_rate", *patch.TaxRate)
+	}
+	if patch.TaxResident != nil {
+		set("tax_resident", *patch.TaxResident)
+	}
+	if patch.Status != nil {
+		set("status", *patch.Status)
+		switch {
+		case !patch.Status.Ended():
+			set("closed_at", nil)
+		case patch.ClosedAt != nil:
+			set("closed_at", *patch.ClosedAt)
+		default:
+			sets = append(sets, "closed_at=COALESCE(closed_at, CURRENT_DATE)")
+		}
+	}
+	if patch.Phone != nil {
+		set("phone", *patch.Phone)
+	}
+	if patch.Email != nil {
+		set("email", *patch.Email)
+	}
+	if patch.Telegram != nil {
+		set("telegram", *patch.Telegram)
+	}
+	if patch.Address != nil {
+		set("address", *patch.Address)
+	}
+	if patch.Notes != nil {
+		set("notes", *patch.Notes)
+	}
+	if patch.Tags != nil {
+		set("tags", pq.Array(*patch.Tags))
+	}
+
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		// нет инвестора — sql.ErrNoRows; отсутствие фонда позиции — ErrFundNotFound
+		if _, err := getInvestor(ctx, tx, id, "FOR UPDATE"); err != nil {
 			return err
 		}
+
+		if patch.InvestedAmount != nil || patch.ProfitShare != nil {
+			share, isDefault, err := updatePosition(ctx, tx, id, patch)
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrFundNotFound
+			}
+			if err != nil {
+				return err
+			}
+			if isDefault && patch.ProfitShare != nil {
+				set("profit_share", share)
+			}
+		}
+
+		if len(sets) == 0 {
+			return nil
+		}
+
+		args = append(args, id)
+		_, err := tx.ExecContext(ctx,
+			`UPDATE investors SET `+strings.Join(sets, ", ")+
+				fmt.Sprintf(` WHERE id=$%d`, len(args)),
+			args...)
+		return err
+	})
+}
+
+// updatePosition меняет сумму и долю позиции инвестора в фонде patch.FundID.
+// Сумма пишется в историю с patch.InvestedAmountFrom, доля — с
+// patch.ProfitShareFrom; возвращает долю, действующую сегодня, и true,
+// если это фонд по умолчанию.
+func updatePosition(ctx context.Context, tx *sql.Tx, investorID int64, patch InvestorPatch) (models.Decimal, bool, error) {
+	pos, err := positionFor(ctx, tx, investorID, patch.FundID, "FOR UPDATE")
+	if err != nil {
+		return models.Decimal{}, false, err
 	}
 
-	if profitShare != nil {
-		_, err := r.db.ExecContext(ctx,
-			`UPDATE investors SET profit_share=$1 WHERE id=$2`,
-			*profitShare, id)
+	var isDefault bool
+	if patch.InvestedAmount != nil {
+		from := time.Now()
+		if patch.InvestedAmountFrom != nil {
+			from = *patch.InvestedAmountFrom
+		}
+		// сумма задним числом не должна менять закрытые месяцы
+		if err := ensurePeriodOpen(ctx, tx, pos.FundID, from); err != nil {
+			return models.Decimal{}, false, err
+		}
+
+		isDefault, err = recordInvestedAmount(ctx, tx, pos.ID, from, *patch.InvestedAmount, patch.ChangedBy)
 		if err != nil {
-			return err
+			return models.Decimal{}, false, err
 		}
 	}
 
+	if patch.ProfitShare == nil {
+		return pos.ProfitShare, isDefault, nil
+	}
+
+	from := time.Now()
+	if patch.ProfitShareFrom != nil {
+		from = *patch.ProfitShareFrom
+	}
+	// доля задним числом не должна менять закрытые месяцы
+	if err := ensurePeriodOpen(ctx, tx, pos.FundID, from); err != nil {
+		return models.Decimal{}, false, err
+	}
+
+	return recordProfitShare(ctx, tx, pos.ID, from, *patch.ProfitShare, patch.ChangedBy)
+}
+
+// ArchiveInvestor — мягкое удаление: статус archived, история остаётся
+func (r *Repository) ArchiveInvestor(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE investors
+         SET status=$2, closed_at=COALESCE(closed_at, CURRENT_DATE)
+         WHERE id=$1`, id, models.InvestorArchived)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
 	return nil
 }
 
-func (r *Repository) DeleteInvestor(ctx context.Context, id int64) error {
-	_, err := r.db.ExecContext(ctx, `DELETE FROM investors WHERE id=$1`, id)
-	return err
+// PurgeInvestor физически удаляет архивного инвестора вместе с выплатами.
+// Нельзя, если хотя бы одна его выплата попадает в закрытый период.
+func (r *Repository) PurgeInvestor(ctx context.Context, id int64) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		inv, err := getInvestor(ctx, tx, id, "FOR UPDATE")
+		if err != nil {
+			return err
+		}
+		if inv.Status != models.InvestorArchived {
+			return ErrInvestorNotArchived
+		}
+
+		var closed bool
+		err = tx.QueryRowContext(ctx,
+			`SELECT EXISTS (
+                 SELECT 1 FROM payouts p
+                 JOIN positions pos ON pos.id = p.position_id
+                 JOIN accounting_periods ap
+                   ON ap.fund_id = pos.fund_id
+                  AND ap.period_month = date_trunc('month', p.period_date)::date
+                 WHERE p.investor_id=$1 AND ap.is_closed
+             )`, id,
+		).Scan(&closed)
+		if err != nil {
+			return err
+		}
+		if closed {
+			return ErrPeriodClosed
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM investors WHERE id=$1`, id)
+		return err
+	})
 }
 
+// GetInvestorByID — в выборке по фонду с условиями позиции;
+// инвестор не из этого фонда — sql.ErrNoRows
 func (r *Repository) GetInvestorByID(ctx context.Context, id int64) (*models.Investor, error) {
+	inv, err := getInvestor(ctx, r.db, id, "")
+	if err != nil || r.fundID == 0 {
+		return inv, err
+	}
+
+	pos, err := positionFor(ctx, r.db, id, r.fundID, "")
+	if errors.Is(err, ErrNoPosition) {
+		return nil, sql.ErrNoRows
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	scoped := pos.Apply(*inv)
+	return &scoped, nil
+}
+
+// GetInvestorByIDAsOf — GetInvestorByID с суммой вложений и долей,
+// действовавшими на дату asOf
+func (r *Repository) GetInvestorByIDAsOf(ctx context.Context, id int64, asOf time.Time) (*models.Investor, error) {
+	inv, err := r.GetInvestorByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	investors := []models.Investor{*inv}
+	if err := applyTermsAt(ctx, r.db, r.fundID, asOf, investors); err != nil {
+		return nil, err
+	}
+	return &investors[0], nil
+}
+
+// getInvestor — suffix = "FOR UPDATE" блокирует строку инвестора до конца транзакции
+func getInvestor(ctx context.Context, q querier, id int64, suffix string) (*models.Investor, error) {
 	var inv models.Investor
-	err := r.db.QueryRowContext(ctx,
-		`SELECT id, full_name, invested_amount, profit_share, created_at
-         FROM investors WHERE id=$1`,
+	err := scanInvestor(q.QueryRowContext(ctx,
+		`SELECT `+investorColumns+`
+         FROM investors WHERE id=$1 `+suffix,
 		id,
-	).Scan(
-		&inv.ID,
-		&inv.FullName,
-		&inv.InvestedAmount,
-		&inv.ProfitShare,
-		&inv.CreatedAt,
-	)
+	), &inv)
 
 	if err != nil {
 		return nil, err
@@ -132,12 +499,76 @@ func (r *Repository) GetInvestorByID(ctx context.Context, id int64) (*models.Inv
 //
 
 func (r *Repository) GetPayouts(ctx context.Context) ([]models.Payout, error) {
-	rows, err := r.db.QueryContext(ctx,
-		`SELECT id, investor_id, period_date, payout_amount, reinvest,
+	return r.queryPayouts(ctx, "")
+}
+
+func (r *Repository) GetPayoutsByInvestor(ctx context.Context, investorID int64) ([]models.Payout, error) {
+	return r.queryPayouts(ctx, "WHERE investor_id=$1", investorID)
+}
+
+// GetPayoutsAsOf — операции с period_date не позже asOf (баланс на дату)
+func (r *Repository) GetPayoutsAsOf(ctx context.Context, asOf time.Time) ([]models.Payout, error) {
+	return r.queryPayouts(ctx, "WHERE period_date <= $1", asOf)
+}
+
+func (r *Repository) GetPayoutsByInvestorAsOf(ctx context.Context, investorID int64, asOf time.Time) ([]models.Payout, error) {
+	return r.queryPayouts(ctx, "WHERE investor_id=$1 AND period_date <= $2", investorID, asOf)
+}
+
+// queryPayouts — queryPayouts в пределах фонда репозитория
+func (r *Repository) queryPayouts(ctx context.Context, where string, args ...any) ([]models.Payout, error) {
+	where, args = payoutsWhere(r.fundID, where, args)
+	return queryPayouts(ctx, r.db, where, args...)
+}
+
+// payoutColumns — порядок колонок совпадает со scanPayout
+const payoutColumns = `id, investor_id, position_id,
+                (SELECT fund_id FROM positions WHERE id = payouts.position_id) AS fund_id,
+                period_date, payout_amount, type, fee_kind, prev_high_water_mark, reinvest,
                 is_withdrawal_profit, is_withdrawal_capital,
-                is_topup, created_at
+                is_topup, distribution_id,
+                reversal_of, reversal_reason,
+                (SELECT rv.id FROM payouts rv WHERE rv.reversal_of = payouts.id) AS reversed_by,
+                tax_of,
+                COALESCE((SELECT src.type = 'profit_withdrawal' FROM payouts src
+                          WHERE src.id = payouts.tax_of), FALSE) AS tax_on_withdrawal,
+                notice_date, penalty_of,
+                created_by, created_at`
+
+func scanPayout(row rowScanner, p *models.Payout) error {
+	return row.Scan(
+		&p.ID,
+		&p.InvestorID,
+		&p.PositionID,
+		&p.FundID,
+		&p.PeriodDate,
+		&p.PayoutAmount,
+		&p.Type,
+		&p.FeeKind,
+		&p.PrevHighWaterMark,
+		&p.Reinvest,
+		&p.IsWithdrawalProfit,
+		&p.IsWithdrawalCapital,
+		&p.IsTopup,
+		&p.DistributionID,
+		&p.ReversalOf,
+		&p.ReversalReason,
+		&p.ReversedBy,
+		&p.TaxOf,
+		&p.TaxOnWithdrawal,
+		&p.NoticeDate,
+		&p.PenaltyOf,
+		&p.CreatedBy,
+		&p.CreatedAt,
+	)
+}
+
+func queryPayouts(ctx context.Context, q querier, where string, args ...any) ([]models.Payout, error) {
+	rows, err := q.QueryContext(ctx,
+		`SELECT `+payoutColumns+`
          FROM payouts
-         ORDER BY period_date, id`)
+         `+where+`
+         ORDER BY period_date, id`, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -146,23 +577,27 @@ func (r *Repository) GetPayouts(ctx context.Context) ([]models.Payout, error) {
 	var out []models.Payout
 	for rows.Next() {
 		var p models.Payout
-		if err := rows.Scan(
-			&p.ID,
-			&p.InvestorID,
-			&p.PeriodDate,
-			&p.PayoutAmount,
-			&p.Reinvest,
-			&p.IsWithdrawalProfit,
-			&p.IsWithdrawalCapital,
-			&p.IsTopup,
-			&p.CreatedAt,
-		); err != nil {
+		if err := scanPayout(rows, &p); err != nil {
 			return nil, err
 		}
 
 		out = append(out, p)
 	}
-	return out, nil
+	return out, rows.Err()
+}
+
+func getPayout(ctx context.Context, q querier, id int64) (*models.Payout, error) {
+	var p models.Payout
+	err := scanPayout(q.QueryRowContext(ctx,
+		`SELECT `+payoutColumns+` FROM payouts WHERE id=$1`, id), &p)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *Repository) GetPayoutByID(ctx context.Context, id int64) (*models.Payout, error) {
+	return getPayout(ctx, r.db, id)
 }
 
 //
@@ -171,20 +606,108 @@ func (r *Repository) GetPayouts(ctx context.Context) ([]models.Payout, error) {
 // ===============================
 //
 
-func (r *Repository) CreatePayout(ctx context.Context, p *models.Payout) error {
-	return r.db.QueryRowContext(ctx,
+// CheckFunc проверяет операцию по актуальным данным инвестора внутри транзакции
+type CheckFunc func(inv models.Investor, payouts []models.Payout, p models.Payout) error
+
+// CreatePayout записывает выплату. Если check задан, он выполняется в той же
+// транзакции после блокировки инвестора — параллельная запись не проскочит.
+// Снятие капитала проверяется по договору инвестора; штраф за снятие
+// в lock-up записывается отдельной строкой в той же транзакции.
+func (r *Repository) CreatePayout(ctx context.Context, p *models.Payout, check CheckFunc) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		if err := checkPayout(ctx, tx, p, check); err != nil {
+			return err
+		}
+		a, err := checkAgreement(ctx, tx, p)
+		if err != nil {
+			return err
+		}
+		if err := insertPayout(ctx, tx, p); err != nil {
+			return err
+		}
+		if err := r.syncUnits(ctx, tx, p); err != nil {
+			return err
+		}
+		if err := r.withholdTax(ctx, tx, p); err != nil {
+			return err
+		}
+		return r.chargePenalty(ctx, tx, p, a, check)
+	})
+}
+
+// checkPayout блокирует инвестора, находит позицию операции (заполняет
+// p.PositionID и p.FundID) и вызывает check по операциям этой позиции
+// (кроме самой p) с условиями позиции.
+// Архивному инвестору операции не добавляются и не меняются.
+func checkPayout(ctx context.Context, tx *sql.Tx, p *models.Payout, check CheckFunc) error {
+	inv, err := getInvestor(ctx, tx, p.InvestorID, "FOR UPDATE")
+	if err != nil {
+		return err
+	}
+	if inv.Status == models.InvestorArchived {
+		return ErrInvestorArchived
+	}
+
+	if err := resolvePosition(ctx, tx, p); err != nil {
+		return err
+	}
+
+	if check == nil {
+		return nil
+	}
+
+	pos, err := positionFor(ctx, tx, p.InvestorID, p.FundID, "FOR UPDATE")
+	if err != nil {
+		return err
+	}
+
+	payouts, err := queryPayouts(ctx, tx, "WHERE position_id=$1 AND id<>$2", p.PositionID, p.ID)
+	if err != nil {
+		return err
+	}
+
+	return check(pos.Apply(*inv), payouts, *p)
+}
+
+// insertPayout — единственная точка вставки в payouts;
+// здесь же запрет записи в закрытый период
+func insertPayout(ctx context.Context, q querier, p *models.Payout) error {
+	if err := resolvePosition(ctx, q, p); err != nil {
+		return err
+	}
+	if err := ensurePeriodOpen(ctx, q, p.FundID, p.PeriodDate); err != nil {
+		return err
+	}
+
+	p.SyncFlags()
+
+	return q.QueryRowContext(ctx,
 		`INSERT INTO payouts (
-            investor_id, period_date, payout_amount,
-            reinvest, is_withdrawal_profit, is_withdrawal_capital, is_topup
+            investor_id, period_date, payout_amount, type, fee_kind,
+            reinvest, is_withdrawal_profit, is_withdrawal_capital, is_topup,
+            distribution_id, reversal_of, reversal_reason, tax_of, created_by,
+            position_id, notice_date, penalty_of, prev_high_water_mark
         )
-        VALUES ($1, $2, $3, $4, $5, $6, FALSE)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
         RETURNING id, created_at`,
 		p.InvestorID,
 		p.PeriodDate,
 		p.PayoutAmount,
+		p.Type,
+		p.FeeKind,
 		p.Reinvest,
 		p.IsWithdrawalProfit,
 		p.IsWithdrawalCapital,
+		p.IsTopup,
+		p.DistributionID,
+		p.ReversalOf,
+		p.ReversalReason,
+		p.TaxOf,
+		p.CreatedBy,
+		p.PositionID,
+		p.NoticeDate,
+		p.PenaltyOf,
+		p.PrevHighWaterMark,
 	).Scan(&p.ID, &p.CreatedAt)
 }
 
@@ -195,17 +718,21 @@ func (r *Repository) CreatePayout(ctx context.Context, p *models.Payout) error {
 //
 
 func (r *Repository) CreateTopup(ctx context.Context, p *models.Payout) error {
-	return r.db.QueryRowContext(ctx,
-		`INSERT INTO payouts (
-            investor_id, period_date, payout_amount,
-            reinvest, is_withdrawal_profit, is_withdrawal_capital, is_topup
-        )
-        VALUES ($1, $2, $3, FALSE, FALSE, FALSE, TRUE)
-        RETURNING id, created_at`,
-		p.InvestorID,
-		p.PeriodDate,
-		p.PayoutAmount,
-	).Scan(&p.ID, &p.CreatedAt)
+	p.Type = models.PayoutTopup
+
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		// пополнение без лимитов, но инвестор должен существовать и не быть в архиве
+		if err := checkPayout(ctx, tx, p, nil); err != nil {
+			return err
+		}
+		if err := insertPayout(ctx, tx, p); err != nil {
+			return err
+		}
+		if err := r.syncUnits(ctx, tx, p); err != nil {
+			return err
+		}
+		return r.withholdTax(ctx, tx, p)
+	})
 }
 
 //
@@ -218,11 +745,31 @@ func (r *Repository) GetUserByEmail(ctx context.Context, email string) (*models.
 	var u models.User
 
 	err := r.db.QueryRowContext(ctx,
-		`SELECT id, email, password_hash, created_at
+		`SELECT id, email, password_hash, is_admin, created_at
          FROM users
          WHERE email=$1`,
 		email,
-	).Scan(&u.ID, &u.Email, &u.PasswordHash, &u.CreatedAt)
+	).Scan(&u.ID, &u.Email, &u.PasswordHash, &u.IsAdmin, &u.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &u, nil
+}
+
+func (r *Repository) GetUserByID(ctx context.Context, id int64) (*models.User, error) {
+	var u models.User
+
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id, email, password_hash, is_admin, created_at
+         FROM users
+         WHERE id=$1`,
+		id,
+	).Scan(&u.ID, &u.Email, &u.PasswordHash, &u.IsAdmin, &u.CreatedAt)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -234,11 +781,22 @@ func (r *Repository) GetUserByEmail(ctx context.Context, email string) (*models.
 	return &u, nil
 }
 
+// CreateUser регистрирует пользователя. Первый зарегистрированный
+// становится администратором — иначе на новой установке некому закрывать
+// периоды; таблица блокируется, чтобы две первые регистрации не стали
+// администраторами обе.
 func (r *Repository) CreateUser(ctx context.Context, u *models.User) error {
-	return r.db.QueryRowContext(ctx,
-		`INSERT INTO users (email, password_hash)
-         VALUES ($1, $2)
-         RETURNING id, created_at`,
-		u.Email, u.PasswordHash,
-	).Scan(&u.ID, &u.CreatedAt)
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx,
+			`LOCK TABLE users IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+			return err
+		}
+
+		return tx.QueryRowContext(ctx,
+			`INSERT INTO users (email, password_hash, is_admin)
+             VALUES ($1, $2, NOT EXISTS (SELECT 1 FROM users))
+             RETURNING id, is_admin, created_at`,
+			u.Email, u.PasswordHash,
+		).Scan(&u.ID, &u.IsAdmin, &u.CreatedAt)
+	})
 }
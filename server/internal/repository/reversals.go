@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"invest/internal/models"
+	"time"
+)
+
+var (
+	ErrPayoutAlreadyReversed = errors.New("payout already reversed")
+	ErrCannotReverseReversal = errors.New("cannot reverse a reversal entry")
+	ErrDistributionPayout    = errors.New("payout belongs to a distribution: roll back the whole run with POST /api/distributions/{id}/rollback")
+)
+
+//
+// ========================
+//      СТОРНО
+// ========================
+//
+
+// ReversePayout создаёт отменяющую запись: те же флаги, сумма с обратным знаком.
+// date == nil — сторно датируется той же датой, что и исходная запись.
+// Архивному инвестору и выплатам распределений сторно не делается;
+// сторно, уменьшающее капитал (пополнение, реинвест, корректировка в плюс),
+// проверяется check как снятие капитала — так же, как в CreatePayout.
+// Сторно performance-комиссии возвращает позиции прежний high-water mark.
+func (r *Repository) ReversePayout(
+	ctx context.Context,
+	id int64,
+	reason string,
+	date *time.Time,
+	userID int64,
+	check CheckFunc,
+) (*models.Payout, error) {
+	var rev models.Payout
+
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		// блокируем исходную запись, чтобы два сторно не прошли одновременно
+		if _, err := tx.ExecContext(ctx,
+			`SELECT id FROM payouts WHERE id=$1 FOR UPDATE`, id); err != nil {
+			return err
+		}
+
+		orig, err := getPayout(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if orig.ReversalOf != nil {
+			return ErrCannotReverseReversal
+		}
+		if orig.ReversedBy != nil {
+			return ErrPayoutAlreadyReversed
+		}
+		if orig.TaxOf != nil {
+			return ErrTaxEntryLinked
+		}
+		if orig.PenaltyOf != nil {
+			return ErrPenaltyEntryLinked
+		}
+		// выплаты распределения отменяются только всем запуском (как и в lockPayoutForChange)
+		if orig.DistributionID != nil {
+			return ErrDistributionPayout
+		}
+
+		period := orig.PeriodDate
+		if date != nil {
+			period = *date
+		}
+
+		rev = models.Payout{
+			InvestorID:     orig.InvestorID,
+			PositionID:     orig.PositionID,
+			PeriodDate:     period,
+			PayoutAmount:   orig.PayoutAmount.Neg(),
+			Type:           orig.Kind(),
+			FeeKind:        orig.FeeKind,
+			ReversalOf:     &orig.ID,
+			ReversalReason: &reason,
+			CreatedBy:      nullableID(userID),
+		}
+
+		if err := checkReversal(ctx, tx, rev, check); err != nil {
+			return err
+		}
+		if err := restoreHighWaterMark(ctx, tx, orig); err != nil {
+			return err
+		}
+
+		if err := insertPayout(ctx, tx, &rev); err != nil {
+			return err
+		}
+		if err := r.syncUnits(ctx, tx, &rev); err != nil {
+			return err
+		}
+
+		// удержанный с выплаты налог и штраф отменяются вместе с ней
+		if err := r.reverseTax(ctx, tx, orig.ID, &rev); err != nil {
+			return err
+		}
+		return r.reversePenalty(ctx, tx, orig.ID, &rev)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &rev, nil
+}
+
+// checkReversal блокирует инвестора (архив — отказ) и, если сторно
+// уменьшает капитал, проверяет его как снятие капитала на ту же сумму
+func checkReversal(ctx context.Context, tx *sql.Tx, rev models.Payout, check CheckFunc) error {
+	switch rev.Kind() {
+	case models.PayoutTopup, models.PayoutReinvest, models.PayoutAdjustment:
+		if rev.PayoutAmount.Sign() < 0 {
+			probe := rev
+			probe.Type = models.PayoutCapitalWithdrawal
+			probe.ReversalOf = nil
+			return checkPayout(ctx, tx, &probe, check)
+		}
+	}
+	return checkPayout(ctx, tx, &rev, nil)
+}
@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"invest/internal/models"
+)
+
+var ErrPayoutLocked = errors.New("payout is part of a reversal or distribution and cannot be changed")
+
+const (
+	RevisionUpdate = "update"
+	RevisionDelete = "delete"
+)
+
+//
+// ========================
+//   ПРАВКА / УДАЛЕНИЕ
+// ========================
+//
+
+// UpdatePayout перезаписывает дату, сумму и флаги выплаты
+// и сохраняет снимок «до/после» в payout_revisions.
+// Налог и штраф за досрочное снятие пересчитываются заново.
+func (r *Repository) UpdatePayout(ctx context.Context, p *models.Payout, userID int64, check CheckFunc) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		old, err := lockPayoutForChange(ctx, tx, p.ID)
+		if err != nil {
+			return err
+		}
+
+		p.SyncFlags()
+
+		// без нового фонда операция остаётся в своей позиции
+		if p.FundID == 0 && p.InvestorID == old.InvestorID {
+			p.PositionID = old.PositionID
+		}
+
+		// прежние налог и штраф не должны влиять на проверку новой суммы;
+		// они пересчитываются ниже по новой дате, сумме и типу
+		if err := deleteLinked(ctx, tx, p.ID, userID); err != nil {
+			return err
+		}
+		if p.NoticeDate == nil {
+			p.NoticeDate = old.NoticeDate
+		}
+
+		if err := checkPayout(ctx, tx, p, check); err != nil {
+			return err
+		}
+		// новая дата тоже не должна попадать в закрытый период фонда
+		if err := ensurePeriodOpen(ctx, tx, p.FundID, p.PeriodDate); err != nil {
+			return err
+		}
+		a, err := checkAgreement(ctx, tx, p)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`UPDATE payouts
+             SET investor_id=$2, period_date=$3, payout_amount=$4, type=$5,
+                 reinvest=$6, is_withdrawal_profit=$7,
+                 is_withdrawal_capital=$8, is_topup=$9, fee_kind=$10,
+                 position_id=$11, notice_date=$12
+             WHERE id=$1`,
+			p.ID,
+			p.InvestorID,
+			p.PeriodDate,
+			p.PayoutAmount,
+			p.Type,
+			p.Reinvest,
+			p.IsWithdrawalProfit,
+			p.IsWithdrawalCapital,
+			p.IsTopup,
+			p.FeeKind,
+			p.PositionID,
+			p.NoticeDate,
+		)
+		if err != nil {
+			return err
+		}
+
+		updated, err := getPayout(ctx, tx, p.ID)
+		if err != nil {
+			return err
+		}
+		*p = *updated
+
+		if err := r.syncUnits(ctx, tx, p); err != nil {
+			return err
+		}
+
+		if err := r.withholdTax(ctx, tx, p); err != nil {
+			return err
+		}
+		if err := r.chargePenalty(ctx, tx, p, a, check); err != nil {
+			return err
+		}
+
+		return insertRevision(ctx, tx, RevisionUpdate, old, updated, userID)
+	})
+}
+
+// DeletePayout удаляет выплату вместе с её налогом и штрафом;
+// последнее состояние каждой строки остаётся в payout_revisions
+func (r *Repository) DeletePayout(ctx context.Context, id, userID int64) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		old, err := lockPayoutForChange(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		return deletePayout(ctx, tx, old, userID)
+	})
+}
+
+// deletePayout удаляет строку с её налогом и штрафом и пишет ревизии удаления;
+// у performance-комиссии возвращается прежний high-water mark
+func deletePayout(ctx context.Context, tx *sql.Tx, p *models.Payout, userID int64) error {
+	if err := restoreHighWaterMark(ctx, tx, p); err != nil {
+		return err
+	}
+	if err := deleteLinked(ctx, tx, p.ID, userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM payouts WHERE id=$1`, p.ID); err != nil {
+		return err
+	}
+
+	return insertRevision(ctx, tx, RevisionDelete, p, nil, userID)
+}
+
+// deleteLinked удаляет налог и штраф выплаты id, записывая ревизию
+// удаления для каждой строки — иначе они исчезли бы из истории бесследно
+func deleteLinked(ctx context.Context, tx *sql.Tx, id, userID int64) error {
+	linked, err := queryPayouts(ctx, tx, "WHERE tax_of=$1 OR penalty_of=$1", id)
+	if err != nil {
+		return err
+	}
+
+	for i := range linked {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM payouts WHERE id=$1`, linked[i].ID); err != nil {
+			return err
+		}
+		if err := insertRevision(ctx, tx, RevisionDelete, &linked[i], nil, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Repository) ListPayoutRevisions(ctx context.Context, payoutID int64) ([]models.PayoutRevision, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, payout_id, action, old_data, new_data, changed_by, changed_at
+         FROM payout_revisions
+         WHERE payout_id=$1
+         ORDER BY changed_at, id`, payoutID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.PayoutRevision
+	for rows.Next() {
+		var rev models.PayoutRevision
+		var newData []byte
+		if err := rows.Scan(
+			&rev.ID,
+			&rev.PayoutID,
+			&rev.Action,
+			&rev.OldData,
+			&newData,
+			&rev.ChangedBy,
+			&rev.ChangedAt,
+		); err != nil {
+			return nil, err
+		}
+		if newData != nil {
+			rev.NewData = newData
+		}
+		out = append(out, rev)
+	}
+	return out, rows.Err()
+}
+
+// lockPayoutForChange блокирует строку и проверяет, что её можно менять:
+// сторно-пары и выплаты распределений меняются только целиком (сторно / откат).
+func lockPayoutForChange(ctx context.Context, tx *sql.Tx, id int64) (*models.Payout, error) {
+	if _, err := tx.ExecContext(ctx,
+		`SELECT id FROM payouts WHERE id=$1 FOR UPDATE`, id); err != nil {
+		return nil, err
+	}
+
+	p, err := getPayout(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.ReversalOf != nil || p.ReversedBy != nil || p.DistributionID != nil {
+		return nil, ErrPayoutLocked
+	}
+	if p.TaxOf != nil {
+		return nil, ErrTaxEntryLinked
+	}
+	if p.PenaltyOf != nil {
+		return nil, ErrPenaltyEntryLinked
+	}
+
+	if err := ensurePeriodOpen(ctx, tx, p.FundID, p.PeriodDate); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func insertRevision(
+	ctx context.Context,
+	tx *sql.Tx,
+	action string,
+	old, updated *models.Payout,
+	userID int64,
+) error {
+	oldData, err := json.Marshal(old)
+	if err != nil {
+		return err
+	}
+
+	// JSONB передаём строкой: []byte lib/pq отправил бы как bytea
+	var newData *string
+	if updated != nil {
+		b, err := json.Marshal(updated)
+		if err != nil {
+			return err
+		}
+		str := string(b)
+		newData = &str
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO payout_revisions (payout_id, action, old_data, new_data, changed_by)
+         VALUES ($1, $2, $3, $4, $5)`,
+		old.ID, action, string(oldData), newData, nullableID(userID))
+	return err
+}
@@ -0,0 +1,242 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"invest/internal/models"
+	"time"
+)
+
+// ScheduledPayoutFunc собирает выплату по расписанию из актуальных данных
+// инвестора (внутри транзакции); ошибка — дату провести нельзя.
+type ScheduledPayoutFunc func(inv models.Investor, payouts []models.Payout) (models.Payout, error)
+
+//
+// ========================
+//       SCHEDULES
+// ========================
+//
+
+const scheduleColumns = `s.id, s.investor_id, s.fund_id, s.type, s.rule, s.amount, s.day_of_month,
+                s.start_date, s.end_date, s.active,
+                (SELECT MAX(due_date) FROM schedule_runs WHERE schedule_id = s.id),
+                s.created_by, s.created_at`
+
+func scanSchedule(row rowScanner, sc *models.Schedule) error {
+	return row.Scan(
+		&sc.ID,
+		&sc.InvestorID,
+		&sc.FundID,
+		&sc.Type,
+		&sc.Rule,
+		&sc.Amount,
+		&sc.DayOfMonth,
+		&sc.StartDate,
+		&sc.EndDate,
+		&sc.Active,
+		&sc.LastRunDate,
+		&sc.CreatedBy,
+		&sc.CreatedAt,
+	)
+}
+
+// ListSchedules — расписания фонда репозитория (см. InFund); activeOnly — только действующие
+func (r *Repository) ListSchedules(ctx context.Context, activeOnly bool) ([]models.Schedule, error) {
+	where := "WHERE ($1 = 0 OR s.fund_id = $1)"
+	if activeOnly {
+		where += " AND s.active"
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+scheduleColumns+`
+         FROM payout_schedules s `+where+`
+         ORDER BY s.id`, r.fundID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.Schedule
+	for rows.Next() {
+		var sc models.Schedule
+		if err := scanSchedule(rows, &sc); err != nil {
+			return nil, err
+		}
+		out = append(out, sc)
+	}
+	return out, rows.Err()
+}
+
+func (r *Repository) GetSchedule(ctx context.Context, id int64) (*models.Schedule, error) {
+	var sc models.Schedule
+	err := scanSchedule(r.db.QueryRowContext(ctx,
+		`SELECT `+scheduleColumns+` FROM payout_schedules s WHERE s.id=$1`, id), &sc)
+	if err != nil {
+		return nil, err
+	}
+	return &sc, nil
+}
+
+// CreateSchedule — FundID = 0 — фонд по умолчанию; у инвестора там должна быть позиция
+func (r *Repository) CreateSchedule(ctx context.Context, sc *models.Schedule) error {
+	pos, err := positionFor(ctx, r.db, sc.InvestorID, sc.FundID, "")
+	if err != nil {
+		return err
+	}
+	sc.FundID = pos.FundID
+
+	sc.Active = true
+	return r.db.QueryRowContext(ctx,
+		`INSERT INTO payout_schedules (
+            investor_id, type, rule, amount, day_of_month, start_date, end_date, created_by,
+            fund_id
+        )
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        RETURNING id, created_at`,
+		sc.InvestorID,
+		sc.Type,
+		sc.Rule,
+		sc.Amount,
+		sc.DayOfMonth,
+		sc.StartDate,
+		sc.EndDate,
+		sc.CreatedBy,
+		sc.FundID,
+	).Scan(&sc.ID, &sc.CreatedAt)
+}
+
+// DeactivateSchedule останавливает расписание; история проведений остаётся
+func (r *Repository) DeactivateSchedule(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE payout_schedules SET active=FALSE WHERE id=$1`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *Repository) ListScheduleRuns(ctx context.Context, scheduleID int64) ([]models.ScheduleRun, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, schedule_id, due_date, status, payout_id, message, created_at
+         FROM schedule_runs WHERE schedule_id=$1
+         ORDER BY due_date DESC`, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.ScheduleRun
+	for rows.Next() {
+		var run models.ScheduleRun
+		if err := rows.Scan(
+			&run.ID,
+			&run.ScheduleID,
+			&run.DueDate,
+			&run.Status,
+			&run.PayoutID,
+			&run.Message,
+			&run.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		out = append(out, run)
+	}
+	return out, rows.Err()
+}
+
+// PostScheduledPayout проводит дату due расписания: запись в schedule_runs
+// и выплата создаются в одной транзакции. Если дата уже обработана
+// (UNIQUE(schedule_id, due_date)), ничего не делает и возвращает false.
+// Снятие капитала проверяется по договору, как ручное: уведомлением служит
+// создание расписания, в lock-up начисляется штраф (его проверяет check).
+func (r *Repository) PostScheduledPayout(ctx context.Context, sc models.Schedule, due time.Time, build ScheduledPayoutFunc, check CheckFunc) (bool, error) {
+	posted := false
+
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		var runID int64
+		err := tx.QueryRowContext(ctx,
+			`INSERT INTO schedule_runs (schedule_id, due_date, status)
+             VALUES ($1, $2, $3)
+             ON CONFLICT (schedule_id, due_date) DO NOTHING
+             RETURNING id`,
+			sc.ID, due, models.ScheduleRunPosted,
+		).Scan(&runID)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		inv, err := getInvestor(ctx, tx, sc.InvestorID, "FOR UPDATE")
+		if err != nil {
+			return err
+		}
+
+		// расчёт — по позиции инвестора в фонде расписания
+		pos, err := positionFor(ctx, tx, sc.InvestorID, sc.FundID, "FOR UPDATE")
+		if err != nil {
+			return err
+		}
+
+		payouts, err := queryPayouts(ctx, tx, "WHERE position_id=$1", pos.ID)
+		if err != nil {
+			return err
+		}
+
+		p, err := build(pos.Apply(*inv), payouts)
+		if err != nil {
+			return err
+		}
+		p.PositionID = pos.ID
+		p.FundID = pos.FundID
+		p.CreatedBy = sc.CreatedBy
+
+		if p.Kind() == models.PayoutCapitalWithdrawal {
+			y, m, d := sc.CreatedAt.Date()
+			notice := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+			p.NoticeDate = &notice
+		}
+		a, err := checkAgreement(ctx, tx, &p)
+		if err != nil {
+			return err
+		}
+
+		if err := insertPayout(ctx, tx, &p); err != nil {
+			return err
+		}
+		if err := r.syncUnits(ctx, tx, &p); err != nil {
+			return err
+		}
+		if err := r.withholdTax(ctx, tx, &p); err != nil {
+			return err
+		}
+		if err := r.chargePenalty(ctx, tx, &p, a, check); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE schedule_runs SET payout_id=$2 WHERE id=$1`, runID, p.ID); err != nil {
+			return err
+		}
+
+		posted = true
+		return nil
+	})
+
+	return posted, err
+}
+
+// SkipScheduledRun помечает дату обработанной без выплаты (с причиной),
+// чтобы планировщик не пытался провести её снова
+func (r *Repository) SkipScheduledRun(ctx context.Context, scheduleID int64, due time.Time, reason string) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO schedule_runs (schedule_id, due_date, status, message)
+         VALUES ($1, $2, $3, $4)
+         ON CONFLICT (schedule_id, due_date) DO NOTHING`,
+		scheduleID, due, models.ScheduleRunSkipped, reason)
+	return err
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"invest/internal/models"
+)
+
+var ErrTaxEntryLinked = errors.New("tax entry is reversed or changed together with its payout")
+
+//
+// ========================
+//     УДЕРЖАНИЕ НАЛОГА
+// ========================
+//
+
+// withholdTax создаёт связанную строку налога для реинвеста или вывода
+// прибыли по ставке инвестора. Сторно, прочие типы и ставка 0 — без налога.
+func (r *Repository) withholdTax(ctx context.Context, q querier, src *models.Payout) error {
+	if src.ReversalOf != nil {
+		return nil
+	}
+
+	kind := src.Kind()
+	if kind != models.PayoutReinvest && kind != models.PayoutProfitWithdrawal {
+		return nil
+	}
+
+	var rate models.Decimal
+	if err := q.QueryRowContext(ctx,
+		`SELECT tax_rate FROM investors WHERE id=$1`, src.InvestorID,
+	).Scan(&rate); err != nil {
+		return err
+	}
+	if rate.Sign() <= 0 {
+		return nil
+	}
+
+	amount := src.PayoutAmount.Abs().MulPercent(rate)
+	if amount.IsZero() {
+		return nil
+	}
+
+	tax := models.Payout{
+		InvestorID:      src.InvestorID,
+		PositionID:      src.PositionID,
+		PeriodDate:      src.PeriodDate,
+		PayoutAmount:    amount,
+		Type:            models.PayoutTax,
+		TaxOf:           &src.ID,
+		TaxOnWithdrawal: kind == models.PayoutProfitWithdrawal,
+		CreatedBy:       src.CreatedBy,
+	}
+
+	if err := insertPayout(ctx, q, &tax); err != nil {
+		return err
+	}
+	return r.syncUnits(ctx, q, &tax)
+}
+
+// reverseTax сторнирует действующий налог, удержанный с выплаты srcID
+func (r *Repository) reverseTax(ctx context.Context, q querier, srcID int64, rev *models.Payout) error {
+	taxes, err := queryPayouts(ctx, q, "WHERE tax_of=$1", srcID)
+	if err != nil {
+		return err
+	}
+
+	for _, tax := range taxes {
+		if tax.ReversedBy != nil {
+			continue
+		}
+
+		taxRev := models.Payout{
+			InvestorID:      tax.InvestorID,
+			PositionID:      tax.PositionID,
+			PeriodDate:      rev.PeriodDate,
+			PayoutAmount:    tax.PayoutAmount.Neg(),
+			Type:            models.PayoutTax,
+			TaxOnWithdrawal: tax.TaxOnWithdrawal,
+			ReversalOf:      &tax.ID,
+			ReversalReason:  rev.ReversalReason,
+			CreatedBy:       rev.CreatedBy,
+		}
+		if err := insertPayout(ctx, q, &taxRev); err != nil {
+			return err
+		}
+		if err := r.syncUnits(ctx, q, &taxRev); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"invest/internal/models"
+	"time"
+)
+
+//
+// ========================
+//   PROFIT SHARE TERMS
+// ========================
+//
+
+// profitShareAt — доля позиции pos на дату %[1]s: последняя запись истории
+// не позже даты, а для дат раньше всей истории — самая ранняя
+const profitShareAt = `COALESCE(
+                (SELECT t.profit_share FROM profit_share_terms t
+                 WHERE t.position_id = pos.id AND t.effective_from <= %[1]s
+                 ORDER BY t.effective_from DESC LIMIT 1),
+                (SELECT t.profit_share FROM profit_share_terms t
+                 WHERE t.position_id = pos.id
+                 ORDER BY t.effective_from LIMIT 1),
+                pos.profit_share)`
+
+// ListProfitShareTerms — история доли инвестора по всем его позициям
+// (в выборке по фонду — только по позиции в этом фонде)
+func (r *Repository) ListProfitShareTerms(ctx context.Context, investorID int64) ([]models.ProfitShareTerm, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT t.id, t.position_id, pos.fund_id, pos.investor_id,
+                t.effective_from,
+                LEAD(t.effective_from) OVER (
+                    PARTITION BY t.position_id ORDER BY t.effective_from
+                ) - 1,
+                t.profit_share, t.created_by, t.created_at
+         FROM profit_share_terms t
+         JOIN positions pos ON pos.id = t.position_id
+         WHERE pos.investor_id=$1 AND ($2 = 0 OR pos.fund_id=$2)
+         ORDER BY pos.fund_id, t.effective_from`,
+		investorID, r.fundID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.ProfitShareTerm
+	for rows.Next() {
+		var t models.ProfitShareTerm
+		var to sql.NullTime
+		if err := rows.Scan(
+			&t.ID,
+			&t.PositionID,
+			&t.FundID,
+			&t.InvestorID,
+			&t.EffectiveFrom,
+			&to,
+			&t.ProfitShare,
+			&t.CreatedBy,
+			&t.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if to.Valid {
+			t.EffectiveTo = &to.Time
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// recordProfitShare записывает долю позиции с даты from (запись на ту же дату
+// заменяется) и обновляет positions.profit_share до доли, действующей сегодня.
+// Возвращает сегодняшнюю долю и признак фонда по умолчанию.
+func recordProfitShare(ctx context.Context, q querier, positionID int64, from time.Time, share models.Decimal, createdBy *int64) (models.Decimal, bool, error) {
+	var current models.Decimal
+	var isDefault bool
+
+	_, err := q.ExecContext(ctx,
+		`INSERT INTO profit_share_terms (position_id, effective_from, profit_share, created_by)
+         VALUES ($1, $2, $3, $4)
+         ON CONFLICT (position_id, effective_from)
+         DO UPDATE SET profit_share = EXCLUDED.profit_share,
+                       created_by = EXCLUDED.created_by,
+                       created_at = NOW()`,
+		positionID, from, share, createdBy)
+	if err != nil {
+		return current, false, err
+	}
+
+	err = q.QueryRowContext(ctx,
+		`UPDATE positions pos
+         SET profit_share = `+fmt.Sprintf(profitShareAt, "CURRENT_DATE")+`
+         WHERE pos.id=$1
+         RETURNING pos.profit_share,
+                   (SELECT is_default FROM funds WHERE id = pos.fund_id)`,
+		positionID,
+	).Scan(&current, &isDefault)
+	return current, isDefault, err
+}
+
+//
+// ========================
+//   INVESTED AMOUNT TERMS
+// ========================
+//
+
+// investedAmountAt — сумма вложений позиции pos на дату %[1]s, по тем же
+// правилам, что profitShareAt
+const investedAmountAt = `COALESCE(
+                (SELECT t.invested_amount FROM invested_amount_terms t
+                 WHERE t.position_id = pos.id AND t.effective_from <= %[1]s
+                 ORDER BY t.effective_from DESC LIMIT 1),
+                (SELECT t.invested_amount FROM invested_amount_terms t
+                 WHERE t.position_id = pos.id
+                 ORDER BY t.effective_from LIMIT 1),
+                pos.invested_amount)`
+
+// recordInvestedAmount записывает сумму вложений позиции с даты from (запись
+// на ту же дату заменяется) и обновляет positions.invested_amount до суммы,
+// действующей сегодня. Возвращает признак фонда по умолчанию.
+func recordInvestedAmount(ctx context.Context, q querier, positionID int64, from time.Time, amount models.Decimal, createdBy *int64) (bool, error) {
+	_, err := q.ExecContext(ctx,
+		`INSERT INTO invested_amount_terms (position_id, effective_from, invested_amount, created_by)
+         VALUES ($1, $2, $3, $4)
+         ON CONFLICT (position_id, effective_from)
+         DO UPDATE SET invested_amount = EXCLUDED.invested_amount,
+                       created_by = EXCLUDED.created_by,
+                       created_at = NOW()`,
+		positionID, from, amount, createdBy)
+	if err != nil {
+		return false, err
+	}
+
+	var isDefault bool
+	err = q.QueryRowContext(ctx,
+		`UPDATE positions pos
+         SET invested_amount = `+fmt.Sprintf(investedAmountAt, "CURRENT_DATE")+`
+         WHERE pos.id=$1
+         RETURNING (SELECT is_default FROM funds WHERE id = pos.fund_id)`,
+		positionID,
+	).Scan(&isDefault)
+	return isDefault, err
+}
+
+// applyTermsAt подставляет инвесторам сумму вложений и долю, действовавшие
+// на дату. В выборке по фонду — из позиции в нём; без фонда сумма — по всем
+// позициям, а доля — из позиции в фонде по умолчанию, как investors.profit_share.
+func applyTermsAt(ctx context.Context, q querier, fundID int64, date time.Time, investors []models.Investor) error {
+	rows, err := q.QueryContext(ctx,
+		`SELECT pos.investor_id,
+                SUM(`+fmt.Sprintf(investedAmountAt, "$2")+`),
+                MAX(CASE WHEN pos.fund_id = $1 OR ($1 = 0 AND f.is_default)
+                         THEN `+fmt.Sprintf(profitShareAt, "$2")+` END)
+         FROM positions pos
+         JOIN funds f ON f.id = pos.fund_id
+         WHERE $1 = 0 OR pos.fund_id = $1
+         GROUP BY pos.investor_id`,
+		fundID, date)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	type terms struct {
+		amount models.Decimal
+		share  *models.Decimal
+	}
+	byInvestor := make(map[int64]terms)
+	for rows.Next() {
+		var investorID int64
+		var t terms
+		if err := rows.Scan(&investorID, &t.amount, &t.share); err != nil {
+			return err
+		}
+		byInvestor[investorID] = t
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i, inv := range investors {
+		t, ok := byInvestor[inv.ID]
+		if !ok {
+			continue
+		}
+		investors[i].InvestedAmount = t.amount
+		if t.share != nil {
+			investors[i].ProfitShare = *t.share
+		}
+	}
+	return nil
+}
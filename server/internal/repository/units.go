@@ -0,0 +1,402 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"invest/internal/models"
+	"time"
+)
+
+// InitialNAV — стоимость пая до первой записи в fund_nav
+var InitialNAV = models.Decimal6FromInt(1)
+
+var (
+	ErrNoUnits        = errors.New("no units outstanding on this date: seed units or record NAV by resultPercent")
+	ErrNAVNotPositive = errors.New("resulting NAV per unit must be > 0")
+)
+
+//
+// ========================
+//      UNITS / NAV
+// ========================
+//
+
+// syncUnits пересоздаёт сделку с паями для операции, меняющей капитал,
+// по стоимости пая фонда операции.
+// Вызывается в той же транзакции, что и запись в payouts.
+func (r *Repository) syncUnits(ctx context.Context, q querier, p *models.Payout) error {
+	if !r.unitMode {
+		return nil
+	}
+
+	if _, err := q.ExecContext(ctx,
+		`DELETE FROM investor_units WHERE payout_id=$1`, p.ID); err != nil {
+		return err
+	}
+
+	// сторно возвращает ровно те паи, что были в исходной сделке,
+	// а не переоценивает сумму по стоимости пая на дату сторно
+	if p.ReversalOf != nil {
+		done, err := reverseUnits(ctx, q, p)
+		if err != nil || done {
+			return err
+		}
+	}
+
+	var amount models.Decimal
+	switch p.Kind() {
+	case models.PayoutTopup, models.PayoutAdjustment:
+		amount = p.PayoutAmount
+	case models.PayoutCapitalWithdrawal, models.PayoutFee:
+		// комиссия оплачивается погашением паёв
+		amount = p.PayoutAmount.Abs().Neg()
+	case models.PayoutTax:
+		// налог с вывода прибыли уже удержан из выплаты
+		if p.TaxOnWithdrawal {
+			return nil
+		}
+		amount = p.PayoutAmount.Abs().Neg()
+	default:
+		// прибыль в паевом режиме двигает NAV, а не количество паёв
+		return nil
+	}
+
+	// сторно списания возвращает паи: знак обратный исходной записи
+	if p.ReversalOf != nil && p.Kind() != models.PayoutTopup && p.Kind() != models.PayoutAdjustment {
+		amount = amount.Neg()
+	}
+
+	if p.FundID == 0 {
+		if err := resolvePosition(ctx, q, p); err != nil {
+			return err
+		}
+	}
+
+	nav, err := navAsOf(ctx, q, p.FundID, p.PeriodDate)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.ExecContext(ctx,
+		`INSERT INTO investor_units (fund_id, investor_id, payout_id, trade_date, units, nav_per_unit, amount)
+         VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		p.FundID,
+		p.InvestorID,
+		p.ID,
+		p.PeriodDate,
+		models.UnitsForAmount(amount, nav),
+		nav,
+		amount,
+	)
+	return err
+}
+
+// reverseUnits записывает для сторно p сделку, обратную сделке исходной
+// записи. false — у исходной записи сделки с паями нет (она сделана до
+// включения паевого режима), тогда сумма пересчитывается по NAV как обычно.
+func reverseUnits(ctx context.Context, q querier, p *models.Payout) (bool, error) {
+	res, err := q.ExecContext(ctx,
+		`INSERT INTO investor_units (fund_id, investor_id, payout_id, trade_date, units, nav_per_unit, amount)
+         SELECT fund_id, investor_id, $1, $2, -units, nav_per_unit, -amount
+         FROM investor_units WHERE payout_id=$3`,
+		p.ID, p.PeriodDate, *p.ReversalOf,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// navAsOf — последняя стоимость пая фонда на дату (или InitialNAV)
+func navAsOf(ctx context.Context, q querier, fundID int64, date time.Time) (models.Decimal6, error) {
+	var nav models.Decimal6
+	err := q.QueryRowContext(ctx,
+		`SELECT nav_per_unit FROM fund_nav
+         WHERE fund_id=$1 AND nav_date <= $2
+         ORDER BY nav_date DESC LIMIT 1`, fundID, date,
+	).Scan(&nav)
+
+	if err == sql.ErrNoRows {
+		return InitialNAV, nil
+	}
+	return nav, err
+}
+
+func unitsAsOf(ctx context.Context, q querier, fundID int64, date time.Time) (models.Decimal6, error) {
+	var units models.Decimal6
+	err := q.QueryRowContext(ctx,
+		`SELECT COALESCE(SUM(units), 0) FROM investor_units
+         WHERE fund_id=$1 AND trade_date <= $2`, fundID, date,
+	).Scan(&units)
+	return units, err
+}
+
+// ListNAV — история стоимости пая; в выборке по фонду — только его
+func (r *Repository) ListNAV(ctx context.Context) ([]models.FundNAV, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, fund_id, nav_date, nav_per_unit, total_units, total_assets,
+                result_percent, created_by, created_at
+         FROM fund_nav
+         WHERE $1 = 0 OR fund_id = $1
+         ORDER BY fund_id, nav_date`, r.fundID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.FundNAV
+	for rows.Next() {
+		var n models.FundNAV
+		if err := rows.Scan(
+			&n.ID,
+			&n.FundID,
+			&n.NAVDate,
+			&n.NAVPerUnit,
+			&n.TotalUnits,
+			&n.TotalAssets,
+			&n.ResultPercent,
+			&n.CreatedBy,
+			&n.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		out = append(out, n)
+	}
+	return out, rows.Err()
+}
+
+// RecordNAV фиксирует результат месяца фонда n.FundID (0 — по умолчанию):
+// либо процентом к прошлой стоимости пая (resultPercent), либо итоговыми
+// активами фонда (totalAssets).
+func (r *Repository) RecordNAV(
+	ctx context.Context,
+	n *models.FundNAV,
+	resultPercent *models.Decimal,
+	totalAssets *models.Decimal,
+) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		fundID, err := resolveFundID(ctx, tx, n.FundID)
+		if err != nil {
+			return err
+		}
+		n.FundID = fundID
+
+		if err := ensurePeriodOpen(ctx, tx, n.FundID, n.NAVDate); err != nil {
+			return err
+		}
+
+		// сериализуем записи NAV между собой
+		if _, err := tx.ExecContext(ctx, `LOCK TABLE fund_nav IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+			return err
+		}
+
+		units, err := unitsAsOf(ctx, tx, n.FundID, n.NAVDate)
+		if err != nil {
+			return err
+		}
+		n.TotalUnits = units
+
+		// прошлая стоимость — строго до этой даты
+		prev, err := navAsOf(ctx, tx, n.FundID, n.NAVDate.AddDate(0, 0, -1))
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case resultPercent != nil:
+			n.NAVPerUnit = prev.GrowPercent(*resultPercent)
+			n.ResultPercent = resultPercent
+		case totalAssets != nil:
+			// активы делить не на что — стоимость пая не определена
+			if units.Sign() <= 0 {
+				return ErrNoUnits
+			}
+			n.NAVPerUnit = models.NAVFromAssets(*totalAssets, units)
+		}
+		if n.NAVPerUnit.Sign() <= 0 {
+			return ErrNAVNotPositive
+		}
+		n.TotalAssets = units.ValueAt(n.NAVPerUnit)
+
+		return tx.QueryRowContext(ctx,
+			`INSERT INTO fund_nav (fund_id, nav_date, nav_per_unit, total_units, total_assets, result_percent, created_by)
+             VALUES ($1, $2, $3, $4, $5, $6, $7)
+             ON CONFLICT (fund_id, nav_date) DO UPDATE
+             SET nav_per_unit=EXCLUDED.nav_per_unit,
+                 total_units=EXCLUDED.total_units,
+                 total_assets=EXCLUDED.total_assets,
+                 result_percent=EXCLUDED.result_percent,
+                 created_by=EXCLUDED.created_by,
+                 created_at=NOW()
+             RETURNING id, created_at`,
+			n.FundID,
+			n.NAVDate,
+			n.NAVPerUnit,
+			n.TotalUnits,
+			n.TotalAssets,
+			n.ResultPercent,
+			n.CreatedBy,
+		).Scan(&n.ID, &n.CreatedAt)
+	})
+}
+
+// SeedUnits выдаёт стартовые паи фонда fundID (0 — по умолчанию) инвесторам,
+// у которых их там ещё нет: капитал позиции на дату переводится в паи
+// по стоимости пая фонда на эту дату.
+func (r *Repository) SeedUnits(ctx context.Context, fundID int64, date time.Time, capitals map[int64]models.Decimal) (int, error) {
+	seeded := 0
+
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		fundID, err := resolveFundID(ctx, tx, fundID)
+		if err != nil {
+			return err
+		}
+
+		nav, err := navAsOf(ctx, tx, fundID, date)
+		if err != nil {
+			return err
+		}
+
+		for investorID, capital := range capitals {
+			if capital.Sign() <= 0 {
+				continue
+			}
+
+			res, err := tx.ExecContext(ctx,
+				`INSERT INTO investor_units (fund_id, investor_id, trade_date, units, nav_per_unit, amount)
+                 SELECT $1, $2, $3, $4, $5, $6
+                 WHERE NOT EXISTS (
+                     SELECT 1 FROM investor_units WHERE fund_id=$1 AND investor_id=$2
+                 )`,
+				fundID,
+				investorID,
+				date,
+				models.UnitsForAmount(capital, nav),
+				nav,
+				capital,
+			)
+			if err != nil {
+				return err
+			}
+			n, err := res.RowsAffected()
+			if err != nil {
+				return err
+			}
+			seeded += int(n)
+		}
+		return nil
+	})
+
+	return seeded, err
+}
+
+// ListUnitHoldings — паи каждого инвестора фонда на дату asOf по стоимости
+// пая на эту дату. У каждого фонда своя стоимость пая, поэтому без выборки
+// по фонду берётся фонд по умолчанию.
+func (r *Repository) ListUnitHoldings(ctx context.Context, asOf time.Time) ([]models.UnitHolding, error) {
+	fundID, err := resolveFundID(ctx, r.db, r.fundID)
+	if err != nil {
+		return nil, err
+	}
+
+	investors, err := listInvestorsIn(ctx, r.db, fundID, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	nav, err := navAsOf(ctx, r.db, fundID, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT investor_id, SUM(units) FROM investor_units
+         WHERE fund_id=$1 AND trade_date <= $2
+         GROUP BY investor_id`, fundID, asOf)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	held := map[int64]models.Decimal6{}
+	for rows.Next() {
+		var id int64
+		var units models.Decimal6
+		if err := rows.Scan(&id, &units); err != nil {
+			return nil, err
+		}
+		held[id] = units
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	out := make([]models.UnitHolding, 0, len(investors))
+	for _, inv := range investors {
+		units := held[inv.ID]
+		out = append(out, models.UnitHolding{
+			Investor:   inv,
+			Units:      units,
+			NAVPerUnit: nav,
+			Value:      units.ValueAt(nav),
+		})
+	}
+	return out, nil
+}
+
+// GetUnitHolding — паи инвестора в фонде (как в ListUnitHoldings) на дату
+// asOf с историей сделок до неё; нет позиции в фонде — sql.ErrNoRows
+func (r *Repository) GetUnitHolding(ctx context.Context, investorID int64, asOf time.Time) (*models.UnitHolding, error) {
+	fundID, err := resolveFundID(ctx, r.db, r.fundID)
+	if err != nil {
+		return nil, err
+	}
+
+	inv, err := r.InFund(fundID).GetInvestorByID(ctx, investorID)
+	if err != nil {
+		return nil, err
+	}
+
+	nav, err := navAsOf(ctx, r.db, fundID, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, investor_id, payout_id, trade_date, units, nav_per_unit, amount, created_at
+         FROM investor_units
+         WHERE fund_id=$1 AND investor_id=$2 AND trade_date <= $3
+         ORDER BY trade_date, id`, fundID, investorID, asOf)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	h := models.UnitHolding{Investor: *inv, NAVPerUnit: nav}
+	for rows.Next() {
+		var t models.UnitTrade
+		if err := rows.Scan(
+			&t.ID,
+			&t.InvestorID,
+			&t.PayoutID,
+			&t.TradeDate,
+			&t.Units,
+			&t.NAVPerUnit,
+			&t.Amount,
+			&t.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		h.Units = h.Units.Add(t.Units)
+		h.Trades = append(h.Trades, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	h.Value = h.Units.ValueAt(nav)
+	return &h, nil
+}
@@ -0,0 +1,101 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"invest/internal/calc"
+	"invest/internal/models"
+	"invest/internal/repository"
+	"log"
+	"time"
+)
+
+// ========================
+//       SCHEDULER
+// ========================
+
+// Scheduler периодически проводит наступившие даты регулярных операций.
+// Повторный запуск безопасен: каждая дата проводится не больше одного раза.
+type Scheduler struct {
+	repo     *repository.Repository
+	interval time.Duration
+}
+
+func New(repo *repository.Repository, interval time.Duration) *Scheduler {
+	return &Scheduler{repo: repo, interval: interval}
+}
+
+// Run проводит операции сразу и затем каждые interval, пока не отменён ctx
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.RunOnce(ctx, time.Now().UTC()); err != nil {
+			log.Printf("scheduler: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce проводит все даты активных расписаний до today включительно
+func (s *Scheduler) RunOnce(ctx context.Context, today time.Time) error {
+	schedules, err := s.repo.ListSchedules(ctx, true)
+	if err != nil {
+		return err
+	}
+
+	for _, sc := range schedules {
+		from := sc.StartDate
+		if sc.LastRunDate != nil {
+			from = sc.LastRunDate.AddDate(0, 0, 1)
+		}
+
+		for _, due := range calc.ScheduleDueDates(sc, from, today) {
+			if err := s.post(ctx, sc, due); err != nil {
+				// сбой базы и т.п. — дата остаётся непроведённой до следующего запуска
+				log.Printf("scheduler: schedule %d, %s: %v", sc.ID, due.Format("2006-01-02"), err)
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Scheduler) post(ctx context.Context, sc models.Schedule, due time.Time) error {
+	posted, err := s.repo.PostScheduledPayout(ctx, sc, due,
+		func(inv models.Investor, payouts []models.Payout) (models.Payout, error) {
+			return calc.ScheduledPayout(sc, inv, payouts, due)
+		}, calc.CheckWithdrawal)
+
+	// отказ по правилам учёта — дату пропускаем с причиной, не повторяем
+	if isSkippable(err) {
+		log.Printf("scheduler: schedule %d, %s skipped: %v", sc.ID, due.Format("2006-01-02"), err)
+		return s.repo.SkipScheduledRun(ctx, sc.ID, due, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+
+	if posted {
+		log.Printf("scheduler: schedule %d, %s posted", sc.ID, due.Format("2006-01-02"))
+	}
+	return nil
+}
+
+func isSkippable(err error) bool {
+	return errors.Is(err, calc.ErrNothingScheduled) ||
+		errors.Is(err, calc.ErrInvestorNotActive) ||
+		errors.Is(err, calc.ErrCapitalExceeded) ||
+		errors.Is(err, calc.ErrProfitExceeded) ||
+		errors.Is(err, repository.ErrNoticeTooShort) ||
+		errors.Is(err, repository.ErrPeriodClosed) ||
+		errors.Is(err, sql.ErrNoRows)
+}